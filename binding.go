@@ -1,10 +1,13 @@
 package inject
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"runtime"
+	"runtime/debug"
 	"strings"
+	"time"
 )
 
 type binding interface {
@@ -12,13 +15,13 @@ type binding interface {
 	// has to be a copy constructor
 	// https://github.com/peter-edge/inject-go/commit/e525825afc80f0de819f35a6afc26a4bf3d3a192
 	// this could be designed better
-	resolvedBinding(*module, *injector) (resolvedBinding, error)
+	resolvedBinding(*module, *injector, bindingKey) (resolvedBinding, error)
 }
 
 type resolvedBinding interface {
 	fmt.Stringer
 	validate(ctx) error
-	get() (interface{}, error)
+	get(ctx) (interface{}, error)
 }
 
 type intermediateBinding struct {
@@ -33,12 +36,12 @@ func (i *intermediateBinding) String() string {
 	return i.bindingKey.String()
 }
 
-func (i *intermediateBinding) resolvedBinding(module *module, injector *injector) (resolvedBinding, error) {
+func (i *intermediateBinding) resolvedBinding(module *module, injector *injector, key bindingKey) (resolvedBinding, error) {
 	binding, ok := module.binding(i.bindingKey)
 	if !ok {
 		return nil, errNoFinalBinding.withTag("bindingKey", i.bindingKey)
 	}
-	return binding.resolvedBinding(module, injector)
+	return binding.resolvedBinding(module, injector, key)
 }
 
 type singletonBinding struct {
@@ -51,9 +54,6 @@ func newSingletonBinding(singleton interface{}) binding {
 }
 
 func (s *singletonBinding) String() string {
-	if inj, ok := s.singleton.(*injector); ok {
-		return fmt.Sprintf(inj.name)
-	}
 	return fmt.Sprintf("singleton %T", s.singleton)
 }
 
@@ -61,11 +61,11 @@ func (s *singletonBinding) validate(ctx) error {
 	return nil
 }
 
-func (s *singletonBinding) get() (interface{}, error) {
+func (s *singletonBinding) get(ctx) (interface{}, error) {
 	return s.singleton, nil
 }
 
-func (s *singletonBinding) resolvedBinding(module *module, injector *injector) (resolvedBinding, error) {
+func (s *singletonBinding) resolvedBinding(module *module, injector *injector, key bindingKey) (resolvedBinding, error) {
 	return &singletonBinding{s.singleton, injector}, nil
 }
 
@@ -73,6 +73,8 @@ type constructorBinding struct {
 	constructor interface{}
 	cache       *constructorBindingCache
 	injector    *injector
+	options     *constructorOptions
+	rejectNil   bool
 }
 
 type constructorBindingCache struct {
@@ -80,8 +82,120 @@ type constructorBindingCache struct {
 	bindingKeys []bindingKey
 }
 
+// constructorOptions are the options of a constructor binding that are set
+// through a ConstructorBuilder or SingletonBuilder. They are shared by all
+// copies of the binding.
+type constructorOptions struct {
+	allowNil bool
+	retry    RetryPolicy
+	timeout  time.Duration
+	scope    Scope
+	perChild bool
+}
+
+// scoped returns the given resolved constructor binding, wrapped in the
+// configured scope, if any.
+func (o *constructorOptions) scoped(injector *injector, key bindingKey, unscoped resolvedBinding) (resolvedBinding, error) {
+	if o.scope == nil {
+		return unscoped, nil
+	}
+	if v, ok := o.scope.(keyVerifier); ok {
+		if err := v.verifyKey(key); err != nil {
+			return nil, err
+		}
+	}
+	return newScopedBinding(injector, key, unscoped, o.scope), nil
+}
+
+// verifyCleanup returns an error if the given constructor of a binding that
+// is not a singleton returns a cleanup function, unless the binding is in a
+// scope that calls the cleanup functions of its instances. Otherwise the
+// cleanup functions of all instances would be kept until the injector is
+// closed.
+func (o *constructorOptions) verifyCleanup(key bindingKey, constructor interface{}) error {
+	constructorReflectType := reflect.TypeOf(constructor)
+	if constructorReflectType.NumOut() < 2 || constructorReflectType.Out(1) != cleanupReflectType {
+		return nil
+	}
+	if _, ok := o.scope.(cleanupOwner); ok {
+		return nil
+	}
+	return errCleanupNotOwned.withTag("bindingKey", key).withTag("constructor", functionTag(constructor))
+}
+
+// scopeString returns the given description of a constructor binding,
+// prefixed with the configured scope, if any.
+func (o *constructorOptions) scopeString(s string) string {
+	if o.scope == nil {
+		return s
+	}
+	return o.scope.String() + " " + s
+}
+
+// construct calls the given construct function of the constructor with the
+// given name, aborting it with an error if it does not complete within the
+// configured timeout. The result of an aborted call is discarded once the call
+// completes.
+func (o *constructorOptions) construct(ctx ctx, name string, construct func(ctx ctx) (interface{}, error)) (interface{}, error) {
+	if o.timeout <= 0 {
+		return construct(ctx)
+	}
+	construction := startConstruction(ctx, o.timeout, construct)
+	if err := construction.await(ctx, o.timeout); err != nil {
+		go func() {
+			<-construction.done
+			discard(name, construction.valueErr)
+		}()
+		return nil, err
+	}
+	return construction.value, construction.err
+}
+
+// construction is a call of a construct function on its own goroutine.
+type construction struct {
+	// the result, which is set when done is closed
+	valueErr
+	done chan struct{}
+}
+
+// startConstruction calls the given construct function on a new goroutine,
+// with a context that is cancelled after the given timeout.
+func startConstruction(ctx ctx, timeout time.Duration, construct func(ctx ctx) (interface{}, error)) *construction {
+	c, cancel := context.WithTimeout(ctx.context, timeout)
+	ctx.context = c
+	res := &construction{done: make(chan struct{})}
+	go func() {
+		defer cancel()
+		res.value, res.err = construct(ctx)
+		close(res.done)
+	}()
+	return res
+}
+
+// await waits for the construction to complete for at most the given timeout.
+func (c *construction) await(ctx ctx, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.context.Done():
+		return ctx.verifyNotDone()
+	case <-timer.C:
+		return errTimeout.
+			withTag("err", context.DeadlineExceeded).
+			withTag("timeout", timeout).
+			withTag("bindingKey", ctx.current.key).
+			withTag("path", "\n"+ctx.current.IndentString("\t* "))
+	}
+}
+
 func newConstructorBinding(constructor interface{}) binding {
-	return &constructorBinding{constructor, newConstructorBindingCache(constructor), nil}
+	return &constructorBinding{
+		constructor: constructor,
+		cache:       newConstructorBindingCache(constructor),
+		options:     &constructorOptions{},
+	}
 }
 
 func newConstructorBindingCache(constructor interface{}) *constructorBindingCache {
@@ -90,7 +204,7 @@ func newConstructorBindingCache(constructor interface{}) *constructorBindingCach
 }
 
 func (c *constructorBinding) String() string {
-	return functionTag(c.constructor)
+	return c.options.scopeString(functionTag(c.constructor))
 }
 
 func (c *constructorBinding) validate(ctx ctx) error {
@@ -101,16 +215,45 @@ func (c *constructorBinding) validate(ctx ctx) error {
 	return nil
 }
 
-func (c *constructorBinding) get() (interface{}, error) {
-	reflectValues, err := c.injector.getReflectValues(c.cache.bindingKeys)
+func (c *constructorBinding) get(ctx ctx) (interface{}, error) {
+	return c.options.construct(ctx, functionTag(c.constructor), c.construct)
+}
+
+func (c *constructorBinding) construct(ctx ctx) (interface{}, error) {
+	defer ctx.startCall()()
+	reflectValues, err := c.injector.getReflectValues(ctx, c.cache.bindingKeys)
 	if err != nil {
 		return nil, unwrap(err).withTag("constructor", functionTag(c.constructor))
 	}
-	return callConstructor(c.constructor, reflectValues)
+	value, err := callConstructor(ctx, c.injector, c.constructor, reflectValues)
+	if err != nil {
+		return nil, err
+	}
+	return verifyResult(ctx, c.constructor, value, c.rejectNil && !c.options.allowNil)
+}
+
+func (c *constructorBinding) resolvedBinding(module *module, injector *injector, key bindingKey) (resolvedBinding, error) {
+	if err := c.options.verifyCleanup(key, c.constructor); err != nil {
+		return nil, err
+	}
+	return c.options.scoped(injector, key, c.resolved(module, injector))
+}
+
+func (c *constructorBinding) resolved(module *module, injector *injector) *constructorBinding {
+	resolved := *c
+	resolved.injector = injector
+	resolved.rejectNil = c.rejectNil || module.rejectNilResults || injector.rejectNilResults
+	return &resolved
+}
+
+func (c *constructorBinding) getOptions() *constructorOptions {
+	return c.options
 }
 
-func (c *constructorBinding) resolvedBinding(module *module, injector *injector) (resolvedBinding, error) {
-	return &constructorBinding{c.constructor, c.cache, injector}, nil
+func (c *constructorBinding) rejectingNil() binding {
+	res := *c
+	res.rejectNil = true
+	return &res
 }
 
 type singletonConstructorBinding struct {
@@ -119,21 +262,40 @@ type singletonConstructorBinding struct {
 }
 
 func newSingletonConstructorBinding(constructor interface{}) binding {
-	return &singletonConstructorBinding{constructorBinding{constructor, newConstructorBindingCache(constructor), nil}, nil}
+	return &singletonConstructorBinding{constructorBinding: *newConstructorBinding(constructor).(*constructorBinding)}
 }
 
-func (s *singletonConstructorBinding) get() (interface{}, error) {
-	return s.loader.load(s.constructorBinding.get)
+func (s *singletonConstructorBinding) String() string {
+	return "singleton " + functionTag(s.constructor)
 }
 
-func (s *singletonConstructorBinding) resolvedBinding(module *module, injector *injector) (resolvedBinding, error) {
-	return &singletonConstructorBinding{constructorBinding{s.constructorBinding.constructor, s.constructorBinding.cache, injector}, newLoader()}, nil
+func (s *singletonConstructorBinding) get(ctx ctx) (interface{}, error) {
+	return s.loader.load(ctx, s.constructorBinding.construct)
+}
+
+func (s *singletonConstructorBinding) resolvedBinding(module *module, injector *injector, key bindingKey) (resolvedBinding, error) {
+	if s.options.perChild {
+		return &perChildBinding{s, module}, nil
+	}
+	return s.resolved(module, injector), nil
+}
+
+func (s *singletonConstructorBinding) resolved(module *module, injector *injector) resolvedBinding {
+	return &singletonConstructorBinding{*s.constructorBinding.resolved(module, injector), newLoader(functionTag(s.constructor), &injector.lifecycle, s.options.retry, s.options.timeout)}
+}
+
+func (s *singletonConstructorBinding) rejectingNil() binding {
+	res := *s
+	res.rejectNil = true
+	return &res
 }
 
 type taggedConstructorBinding struct {
 	constructor interface{}
 	cache       *taggedConstructorBindingCache
 	injector    *injector
+	options     *constructorOptions
+	rejectNil   bool
 }
 
 type taggedConstructorBindingCache struct {
@@ -143,7 +305,11 @@ type taggedConstructorBindingCache struct {
 }
 
 func newTaggedConstructorBinding(constructor interface{}) binding {
-	return &taggedConstructorBinding{constructor, newTaggedConstructorBindingCache(constructor), nil}
+	return &taggedConstructorBinding{
+		constructor: constructor,
+		cache:       newTaggedConstructorBindingCache(constructor),
+		options:     &constructorOptions{},
+	}
 }
 
 func newTaggedConstructorBindingCache(constructor interface{}) *taggedConstructorBindingCache {
@@ -153,25 +319,54 @@ func newTaggedConstructorBindingCache(constructor interface{}) *taggedConstructo
 }
 
 func (t *taggedConstructorBinding) String() string {
-	return functionTag(t.constructor)
+	return t.options.scopeString(functionTag(t.constructor))
 }
 
 func (t *taggedConstructorBinding) validate(ctx ctx) error {
 	return t.injector.validateBindings(ctx, t.cache.bindingKeys)
 }
 
-func (t *taggedConstructorBinding) get() (interface{}, error) {
-	reflectValues, err := t.injector.getReflectValues(t.cache.bindingKeys)
+func (t *taggedConstructorBinding) get(ctx ctx) (interface{}, error) {
+	return t.options.construct(ctx, functionTag(t.constructor), t.construct)
+}
+
+func (t *taggedConstructorBinding) construct(ctx ctx) (interface{}, error) {
+	defer ctx.startCall()()
+	reflectValues, err := t.injector.getReflectValues(ctx, t.cache.bindingKeys)
 	if err != nil {
 		return nil, err
 	}
 	structReflectValue := newStructReflectValue(t.cache.inReflectType)
 	populateStructReflectValue(&structReflectValue, reflectValues)
-	return callConstructor(t.constructor, []reflect.Value{structReflectValue})
+	value, err := callConstructor(ctx, t.injector, t.constructor, []reflect.Value{structReflectValue})
+	if err != nil {
+		return nil, err
+	}
+	return verifyResult(ctx, t.constructor, value, t.rejectNil && !t.options.allowNil)
+}
+
+func (t *taggedConstructorBinding) resolvedBinding(module *module, injector *injector, key bindingKey) (resolvedBinding, error) {
+	if err := t.options.verifyCleanup(key, t.constructor); err != nil {
+		return nil, err
+	}
+	return t.options.scoped(injector, key, t.resolved(module, injector))
+}
+
+func (t *taggedConstructorBinding) resolved(module *module, injector *injector) *taggedConstructorBinding {
+	resolved := *t
+	resolved.injector = injector
+	resolved.rejectNil = t.rejectNil || module.rejectNilResults || injector.rejectNilResults
+	return &resolved
+}
+
+func (t *taggedConstructorBinding) getOptions() *constructorOptions {
+	return t.options
 }
 
-func (t *taggedConstructorBinding) resolvedBinding(module *module, injector *injector) (resolvedBinding, error) {
-	return &taggedConstructorBinding{t.constructor, t.cache, injector}, nil
+func (t *taggedConstructorBinding) rejectingNil() binding {
+	res := *t
+	res.rejectNil = true
+	return &res
 }
 
 type taggedSingletonConstructorBinding struct {
@@ -180,29 +375,142 @@ type taggedSingletonConstructorBinding struct {
 }
 
 func newTaggedSingletonConstructorBinding(constructor interface{}) binding {
-	return &taggedSingletonConstructorBinding{taggedConstructorBinding{constructor, newTaggedConstructorBindingCache(constructor), nil}, nil}
+	return &taggedSingletonConstructorBinding{taggedConstructorBinding: *newTaggedConstructorBinding(constructor).(*taggedConstructorBinding)}
+}
+
+func (t *taggedSingletonConstructorBinding) String() string {
+	return "singleton " + functionTag(t.constructor)
+}
+
+func (t *taggedSingletonConstructorBinding) get(ctx ctx) (interface{}, error) {
+	return t.loader.load(ctx, t.taggedConstructorBinding.construct)
+}
+
+func (t *taggedSingletonConstructorBinding) resolvedBinding(module *module, injector *injector, key bindingKey) (resolvedBinding, error) {
+	if t.options.perChild {
+		return &perChildBinding{t, module}, nil
+	}
+	return t.resolved(module, injector), nil
+}
+
+func (t *taggedSingletonConstructorBinding) resolved(module *module, injector *injector) resolvedBinding {
+	return &taggedSingletonConstructorBinding{*t.taggedConstructorBinding.resolved(module, injector), newLoader(functionTag(t.constructor), &injector.lifecycle, t.options.retry, t.options.timeout)}
 }
 
-func (t *taggedSingletonConstructorBinding) get() (interface{}, error) {
-	return t.loader.load(t.taggedConstructorBinding.get)
+func (t *taggedSingletonConstructorBinding) rejectingNil() binding {
+	res := *t
+	res.rejectNil = true
+	return &res
 }
 
-func (t *taggedSingletonConstructorBinding) resolvedBinding(module *module, injector *injector) (resolvedBinding, error) {
-	return &taggedSingletonConstructorBinding{taggedConstructorBinding{t.taggedConstructorBinding.constructor, t.taggedConstructorBinding.cache, injector}, newLoader()}, nil
+// perChildBinding is the binding of a singleton that is created once per child
+// injector (see SingletonBuilder.PerChildInjector). The injector declaring the
+// singleton only holds this binding, which is resolved against each of its
+// child injectors when they are created.
+type perChildBinding struct {
+	singleton perChildSingleton
+	module    *module
 }
 
-func callConstructor(constructor interface{}, reflectValues []reflect.Value) (interface{}, error) {
-	returnValues := reflect.ValueOf(constructor).Call(reflectValues)
-	if len(returnValues) == 2 {
-		ret := returnValues[1].Interface()
+// perChildSingleton is implemented by the singleton bindings that can be
+// resolved per child injector.
+type perChildSingleton interface {
+	fmt.Stringer
+	resolved(module *module, injector *injector) resolvedBinding
+}
+
+func (p *perChildBinding) String() string {
+	return "per child " + p.singleton.String()
+}
+
+func (p *perChildBinding) validate(ctx) error {
+	// the dependencies may be bound in the child injectors only and are
+	// validated when a child injector is created
+	return nil
+}
+
+func (p *perChildBinding) get(ctx ctx) (interface{}, error) {
+	return nil, errPerChildInjector.withTag("bindingKey", ctx.current.key)
+}
+
+// resolve returns the binding of the singleton for the given child injector.
+func (p *perChildBinding) resolve(child *injector) resolvedBinding {
+	return p.singleton.resolved(p.module, child)
+}
+
+// nilRejecter is implemented by bindings that can reject nil results.
+type nilRejecter interface {
+	// rejectingNil returns a copy of the binding that rejects nil results,
+	// unless they are explicitly allowed for the binding.
+	rejectingNil() binding
+}
+
+// verifyResult returns the given value created by the constructor, or an error
+// if the value is nil and nil values are rejected.
+func verifyResult(ctx ctx, constructor interface{}, value interface{}, rejectNil bool) (interface{}, error) {
+	if rejectNil && isNil(value) {
+		return nil, errNilResult.
+			withTag("constructor", functionTag(constructor), true).
+			withTag("bindingKey", ctx.current.key)
+	}
+	return value, nil
+}
+
+// callConstructor calls the constructor with the given arguments. A cleanup
+// function returned by the constructor is registered with the injector, or
+// with the scope of the instance, and called when the injector is closed or
+// the scope is exited.
+func callConstructor(ctx ctx, injector *injector, constructor interface{}, reflectValues []reflect.Value) (interface{}, error) {
+	returnValues, err := callFunction(ctx, constructor, reflectValues)
+	if err != nil {
+		return nil, err
+	}
+	last := returnValues[len(returnValues)-1]
+	if len(returnValues) > 1 && last.Type() != cleanupReflectType {
+		ret := last.Interface()
 		if ret != nil {
 			return nil, errConstructorCall.withTag("err", ret, true).
 				withTag("constructor", functionTag(constructor))
 		}
 	}
+	if len(returnValues) > 1 {
+		if cleanup := returnValues[1]; cleanup.Type() == cleanupReflectType && !cleanup.IsNil() {
+			ctx.lifecycleOf(injector).trackCleanup(functionTag(constructor), cleanup.Interface().(func()))
+		}
+	}
 	return returnValues[0].Interface(), nil
 }
 
+// callFunction calls the given function with the given arguments and turns a
+// panic in the function into an error.
+func callFunction(ctx ctx, function interface{}, reflectValues []reflect.Value) (returnValues []reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errPanic.
+				withTag("panic", fmt.Sprint(r), true).
+				withTag("function", functionTag(function), true).
+				withTag("bindingKey", ctx.current.key, true).
+				withTag("path", "\n"+ctx.current.IndentString("\t* "), true).
+				withTag("stack", "\n"+string(debug.Stack()), true)
+		}
+	}()
+	return reflect.ValueOf(function).Call(reflectValues), nil
+}
+
+// isNil returns true if the given value is nil or a nil pointer, map, channel,
+// function or interface. Nil slices are valid (empty) values.
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Chan, reflect.Func, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
 func functionTag(fn interface{}) string {
 	fnSignature := strings.TrimPrefix(fmt.Sprintf("%T", fn), "func")
 	return fmt.Sprintf("<%s%s>", runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name(), fnSignature)
@@ -26,13 +26,14 @@ func (o *override) With(overrides ...Module) Module {
 
 func addBindings(target *module, source *module) {
 	for k, v := range source.bindings {
-		target.bindings[k] = v
+		target.bindings[k] = source.exportedBinding(v)
 	}
 	// also add any binding errors from the source modules, because
 	// error checking is only done at creation of the injector
 	target.bindingErrors = append(target.bindingErrors, source.bindingErrors...)
 	// plus the eager singletons
 	target.eager = append(target.eager, source.eager...)
+	target.injectorRejectsNil = target.injectorRejectsNil || source.injectorRejectsNil
 }
 
 // Override returns a builder that allows replacing bindings of the given
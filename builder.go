@@ -2,10 +2,12 @@ package inject
 
 import (
 	"reflect"
+	"time"
 )
 
 var (
-	errorReflectType = reflect.TypeOf((*error)(nil)).Elem()
+	errorReflectType   = reflect.TypeOf((*error)(nil)).Elem()
+	cleanupReflectType = reflect.TypeOf((func())(nil))
 )
 
 type noOpBuilder struct{}
@@ -18,25 +20,35 @@ func (n *noOpBuilder) To(to interface{}) {}
 
 func (n *noOpBuilder) ToSingleton(singleton interface{}) {}
 
-func (n *noOpBuilder) ToConstructor(constructor interface{}) {}
+func (n *noOpBuilder) ToConstructor(constructor interface{}) ConstructorBuilder {
+	return (*constructorBuilder)(nil)
+}
 
 func (n *noOpBuilder) ToSingletonConstructor(construtor interface{}) SingletonBuilder {
-	return nil
+	return (*singletonBuilder)(nil)
+}
+
+func (n *noOpBuilder) ToScopedConstructor(constructor interface{}, scope Scope) ConstructorBuilder {
+	return (*constructorBuilder)(nil)
 }
 
-func (n *noOpBuilder) ToTaggedConstructor(constructor interface{}) {}
+func (n *noOpBuilder) ToTaggedConstructor(constructor interface{}) ConstructorBuilder {
+	return (*constructorBuilder)(nil)
+}
 
 func (n *noOpBuilder) ToTaggedSingletonConstructor(constructor interface{}) SingletonBuilder {
-	return nil
+	return (*singletonBuilder)(nil)
 }
 
 type baseBuilder struct {
 	module      *module
 	bindingKeys []bindingKey
+	// whether the bindings are default bindings, see Module.BindDefault
+	isDefault bool
 }
 
 func newBuilder(module *module, bindingKeys []bindingKey) InterfaceBuilder {
-	return &baseBuilder{module, bindingKeys}
+	return &baseBuilder{module: module, bindingKeys: bindingKeys}
 }
 
 func (b *baseBuilder) To(to interface{}) {
@@ -47,46 +59,95 @@ func (b *baseBuilder) ToSingleton(singleton interface{}) {
 	b.to(singleton, verifyBindingReflectType, newSingletonBinding)
 }
 
-func (b *baseBuilder) ToConstructor(constructor interface{}) {
-	b.to(constructor, verifyConstructorReflectType, newConstructorBinding)
+func (b *baseBuilder) ToConstructor(constructor interface{}) ConstructorBuilder {
+	binding := b.to(constructor, verifyConstructorReflectType, newConstructorBinding)
+	return newConstructorBuilder(binding)
 }
 
 func (b *baseBuilder) ToSingletonConstructor(constructor interface{}) SingletonBuilder {
-	b.to(constructor, verifyConstructorReflectType, newSingletonConstructorBinding)
-	return newSingletonBuilder(b.module, b.bindingKeys[0].reflectType())
+	binding := b.to(constructor, verifyConstructorReflectType, newSingletonConstructorBinding)
+	return newSingletonBuilder(b.module, b.bindingKeys[0].reflectType(), optionsOf(binding))
 }
 
-func (b *baseBuilder) ToTaggedConstructor(constructor interface{}) {
-	b.to(constructor, verifyTaggedConstructorReflectType, newTaggedConstructorBinding)
+func (b *baseBuilder) ToScopedConstructor(constructor interface{}, scope Scope) ConstructorBuilder {
+	return b.ToConstructor(constructor).In(scope)
+}
+
+func (b *baseBuilder) ToTaggedConstructor(constructor interface{}) ConstructorBuilder {
+	binding := b.to(constructor, verifyTaggedConstructorReflectType, newTaggedConstructorBinding)
+	return newConstructorBuilder(binding)
 }
 
 func (b *baseBuilder) ToTaggedSingletonConstructor(constructor interface{}) SingletonBuilder {
-	b.to(constructor, verifyTaggedConstructorReflectType, newTaggedSingletonConstructorBinding)
-	return newSingletonBuilder(b.module, b.bindingKeys[0].reflectType())
+	binding := b.to(constructor, verifyTaggedConstructorReflectType, newTaggedSingletonConstructorBinding)
+	return newSingletonBuilder(b.module, b.bindingKeys[0].reflectType(), optionsOf(binding))
 }
 
-func (b *baseBuilder) to(object interface{}, verifyFunc func(reflect.Type, reflect.Type) error, newBindingFunc func(interface{}) binding) {
+// to creates the binding for the given object and binds all binding keys to
+// it. Returns nil if the object cannot be bound.
+func (b *baseBuilder) to(object interface{}, verifyFunc func(reflect.Type, reflect.Type) error, newBindingFunc func(interface{}) binding) binding {
 	objectReflectType := reflect.TypeOf(object)
 	for _, bindingKey := range b.bindingKeys {
 		if err := verifyFunc(bindingKey.reflectType(), objectReflectType); err != nil {
 			b.module.addBindingError(err)
-			return
+			return nil
 		}
 	}
 	binding := newBindingFunc(object)
 	for _, bindingKey := range b.bindingKeys {
 		b.setBinding(bindingKey, binding)
 	}
+	return binding
 }
 
 func (b *baseBuilder) setBinding(bindingKey bindingKey, binding binding) {
+	if b.isDefault {
+		binding = &defaultBinding{binding: binding}
+	}
 	b.module.setBinding(bindingKey, binding)
 }
 
+type constructorBuilder struct {
+	options *constructorOptions
+}
+
+func newConstructorBuilder(binding binding) ConstructorBuilder {
+	options := optionsOf(binding)
+	if options == nil {
+		return (*constructorBuilder)(nil)
+	}
+	return &constructorBuilder{options}
+}
+
+func (b *constructorBuilder) AllowNil() ConstructorBuilder {
+	if b == nil {
+		return b
+	}
+	b.options.allowNil = true
+	return b
+}
+
+func (b *constructorBuilder) In(scope Scope) ConstructorBuilder {
+	if b == nil {
+		return b
+	}
+	b.options.scope = scope
+	return b
+}
+
+func (b *constructorBuilder) WithTimeout(timeout time.Duration) ConstructorBuilder {
+	if b == nil {
+		return b
+	}
+	b.options.timeout = timeout
+	return b
+}
+
 type singletonBuilder struct {
-	module *module
-	t      reflect.Type
-	fn     interface{}
+	module  *module
+	t       reflect.Type
+	fn      interface{}
+	options *constructorOptions
 }
 
 func (b *singletonBuilder) Eagerly() {
@@ -104,8 +165,49 @@ func (b *singletonBuilder) EagerlyAndCall(function interface{}) {
 	b.module.eager = append(b.module.eager, b)
 }
 
-func newSingletonBuilder(module *module, t reflect.Type) SingletonBuilder {
-	return &singletonBuilder{module: module, t: t}
+func (b *singletonBuilder) AllowNil() SingletonBuilder {
+	if b == nil || b.options == nil {
+		return b
+	}
+	b.options.allowNil = true
+	return b
+}
+
+func (b *singletonBuilder) WithRetry(retry RetryPolicy) SingletonBuilder {
+	if b == nil || b.options == nil {
+		return b
+	}
+	b.options.retry = retry
+	return b
+}
+
+func (b *singletonBuilder) WithTimeout(timeout time.Duration) SingletonBuilder {
+	if b == nil || b.options == nil {
+		return b
+	}
+	b.options.timeout = timeout
+	return b
+}
+
+func (b *singletonBuilder) PerChildInjector() SingletonBuilder {
+	if b == nil || b.options == nil {
+		return b
+	}
+	b.options.perChild = true
+	return b
+}
+
+func newSingletonBuilder(module *module, t reflect.Type, options *constructorOptions) SingletonBuilder {
+	return &singletonBuilder{module: module, t: t, options: options}
+}
+
+// optionsOf returns the options of the given constructor binding or nil if
+// the binding is not a constructor binding.
+func optionsOf(binding binding) *constructorOptions {
+	if b, ok := binding.(interface{ getOptions() *constructorOptions }); ok {
+		return b.getOptions()
+	}
+	return nil
 }
 
 func verifyBindingReflectType(bindingKeyReflectType reflect.Type, bindingReflectType reflect.Type) error {
@@ -135,9 +237,12 @@ func verifyTaggedConstructorReflectType(bindingKeyReflectType reflect.Type, cons
 	return verifyConstructorReturnValues(bindingKeyReflectType, constructorReflectType)
 }
 
+// verifyConstructorReturnValues verifies that the constructor returns the
+// value to be bound, optionally followed by a cleanup function of type func()
+// and/or an error.
 func verifyConstructorReturnValues(bindingKeyReflectType reflect.Type, constructorReflectType reflect.Type) error {
 	numOut := constructorReflectType.NumOut()
-	if numOut < 1 || numOut > 2 {
+	if numOut < 1 || numOut > 3 {
 		return errConstructorReturnValuesInvalid.withTag("constructorReflectType", constructorReflectType)
 	}
 	if bindingKeyReflectType != nil {
@@ -145,8 +250,16 @@ func verifyConstructorReturnValues(bindingKeyReflectType reflect.Type, construct
 			return err
 		}
 	}
-	if numOut == 2 && !constructorReflectType.Out(1).AssignableTo(errorReflectType) {
-		return errConstructorReturnValuesInvalid.withTag("constructorReflectType", constructorReflectType)
+	switch numOut {
+	case 2:
+		out := constructorReflectType.Out(1)
+		if out != cleanupReflectType && !out.AssignableTo(errorReflectType) {
+			return errConstructorReturnValuesInvalid.withTag("constructorReflectType", constructorReflectType)
+		}
+	case 3:
+		if constructorReflectType.Out(1) != cleanupReflectType || !constructorReflectType.Out(2).AssignableTo(errorReflectType) {
+			return errConstructorReturnValuesInvalid.withTag("constructorReflectType", constructorReflectType)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,302 @@
+package inject
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ChildInjectorCache is a bounded cache of child injectors, e.g. one per
+// tenant of a multi-tenant service. Child injectors are created on demand for
+// a key with the modules returned by the Modules function, and evicted once
+// the cache exceeds its MaxSize (least recently used first) or they have not
+// been used for the IdleTimeout:
+//
+//	cache := &inject.ChildInjectorCache{
+//		Parent: injector,
+//		Modules: func(tenant string) ([]inject.Module, error) {
+//			return []inject.Module{tenantModule(tenant)}, nil
+//		},
+//		MaxSize:     1000,
+//		IdleTimeout: time.Hour,
+//	}
+//	defer cache.Close(ctx)
+//	...
+//	tenantInjector, err := cache.Get(ctx, tenant)
+//
+// Evicted child injectors are closed in the background, which stops their
+// singletons (see Injector.Close), even if they are still in use (see Get). Concurrent requests for the same key share
+// a single creation of the child injector. Failed creations are not cached.
+//
+// The configuration must not be changed once the cache is in use.
+type ChildInjectorCache struct {
+	// Parent is the parent injector of all child injectors.
+	Parent Injector
+	// Modules returns the modules of the child injector for the given key.
+	Modules func(key string) ([]Module, error)
+	// OverridesType is the overrides type passed to
+	// Injector.NewNamedChildInjector. Optional.
+	OverridesType interface{}
+	// MaxSize is the maximum number of cached child injectors. Zero means no
+	// limit.
+	MaxSize int
+	// IdleTimeout is the time after which a child injector that has not been
+	// used is evicted. Zero means no timeout.
+	IdleTimeout time.Duration
+	// Clock provides the current time. Defaults to the system clock.
+	Clock Clock
+	// OnEvict is called after an evicted child injector has been closed, with
+	// the error returned by Injector.Close. Optional.
+	OnEvict func(key string, err error)
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// the entries by key
+	entries map[string]*list.Element
+	// the entries in order of their last use, most recent first
+	lru *list.List
+	// closes the goroutine evicting idle child injectors
+	stop chan struct{}
+	// whether Close has been called
+	closed bool
+	// the pending creations and evictions of child injectors
+	pending sync.WaitGroup
+}
+
+type childCacheEntry struct {
+	key string
+	// closed once the child injector has been created
+	ready    chan struct{}
+	created  bool
+	injector Injector
+	err      error
+	lastUsed time.Time
+}
+
+// Get returns the child injector for the given key, creating it if it is not
+// cached. Waiting for a concurrent creation is aborted once the given context
+// is done.
+//
+// The returned child injector is closed as soon as it is evicted, even if it
+// is still in use, e.g. by a request that obtained it earlier: its stopped
+// singletons may fail, and getting values from it fails afterwards. Callers
+// should therefore call Get for every unit of work instead of holding on to
+// the child injector, and choose a MaxSize that exceeds the number of keys in
+// use at the same time.
+func (c *ChildInjectorCache) Get(ctx context.Context, key string) (Injector, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, errCacheClosed
+	}
+	c.init()
+	c.evictIdle()
+
+	e, ok := c.entries[key]
+	if ok {
+		c.lru.MoveToFront(e)
+	} else {
+		e = c.lru.PushFront(&childCacheEntry{key: key, ready: make(chan struct{})})
+		c.entries[key] = e
+		c.pending.Add(1)
+		go c.create(e)
+		c.evictOverflow()
+	}
+	entry := e.Value.(*childCacheEntry)
+	entry.lastUsed = c.now()
+	c.mu.Unlock()
+
+	select {
+	case <-entry.ready:
+		return entry.injector, entry.err
+	case <-ctx.Done():
+		return nil, errContextDone.withTag("err", ctx.Err()).withTag("key", key)
+	}
+}
+
+// Len returns the number of cached child injectors, including those being
+// created.
+func (c *ChildInjectorCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Close closes all cached child injectors and waits for pending creations and
+// evictions. The cache cannot be used anymore afterwards.
+func (c *ChildInjectorCache) Close(ctx context.Context) error {
+	c.mu.Lock()
+	c.closed = true
+	var injectors []Injector
+	if c.lru != nil {
+		for e := c.lru.Front(); e != nil; e = e.Next() {
+			if entry := e.Value.(*childCacheEntry); entry.created && entry.err == nil {
+				injectors = append(injectors, entry.injector)
+			}
+		}
+		c.entries, c.lru = nil, nil
+	}
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+	c.mu.Unlock()
+
+	var errs []error
+	for _, injector := range injectors {
+		if err := injector.Close(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, errCloseAborted.withTag("err", ctx.Err()))
+	}
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	err := errCloseFailed
+	for i, e := range errs {
+		err = err.withTag(strconv.Itoa(i+1), e.Error(), true)
+	}
+	return err
+}
+
+// init initializes the cache on first use. Must be called with the lock held.
+func (c *ChildInjectorCache) init() {
+	if c.entries != nil {
+		return
+	}
+	c.entries = make(map[string]*list.Element)
+	c.lru = list.New()
+	if c.IdleTimeout > 0 {
+		c.stop = make(chan struct{})
+		go c.evictIdlePeriodically(c.stop)
+	}
+}
+
+// create creates the child injector of the given entry.
+func (c *ChildInjectorCache) create(e *list.Element) {
+	defer c.pending.Done()
+	entry := e.Value.(*childCacheEntry)
+
+	var injector Injector
+	modules, err := c.Modules(entry.key)
+	if err == nil {
+		injector, err = c.Parent.NewNamedChildInjector(entry.key, c.OverridesType, modules...)
+	}
+
+	c.mu.Lock()
+	switch {
+	case c.closed:
+		if err == nil {
+			_ = injector.Close(context.Background())
+		}
+		err = errCacheClosed
+	case err != nil:
+		// do not cache failed creations
+		c.remove(e)
+	}
+	entry.created, entry.injector, entry.err = true, injector, err
+	c.mu.Unlock()
+	close(entry.ready)
+}
+
+// evictIdle evicts the child injectors that have not been used for the idle
+// timeout. Must be called with the lock held.
+func (c *ChildInjectorCache) evictIdle() {
+	if c.IdleTimeout <= 0 {
+		return
+	}
+	now := c.now()
+	for e := c.lru.Back(); e != nil; {
+		prev := e.Prev()
+		entry := e.Value.(*childCacheEntry)
+		if now.Sub(entry.lastUsed) < c.IdleTimeout {
+			// all remaining entries have been used more recently
+			return
+		}
+		if entry.created {
+			c.evict(e)
+		}
+		e = prev
+	}
+}
+
+// evictOverflow evicts the least recently used child injectors that exceed
+// the maximum size. Child injectors that are still being created are not
+// evicted. Must be called with the lock held.
+func (c *ChildInjectorCache) evictOverflow() {
+	if c.MaxSize <= 0 {
+		return
+	}
+	for e := c.lru.Back(); e != nil && len(c.entries) > c.MaxSize; {
+		prev := e.Prev()
+		if e.Value.(*childCacheEntry).created {
+			c.evict(e)
+		}
+		e = prev
+	}
+}
+
+// evict removes the given entry and closes its child injector in the
+// background. Must be called with the lock held.
+func (c *ChildInjectorCache) evict(e *list.Element) {
+	c.remove(e)
+	entry := e.Value.(*childCacheEntry)
+	c.pending.Add(1)
+	go func() {
+		defer c.pending.Done()
+		err := entry.injector.Close(context.Background())
+		if c.OnEvict != nil {
+			c.OnEvict(entry.key, err)
+		}
+	}()
+}
+
+// remove removes the given entry from the cache, unless it has been removed
+// already. Must be called with the lock held.
+func (c *ChildInjectorCache) remove(e *list.Element) {
+	key := e.Value.(*childCacheEntry).key
+	if c.entries[key] == e {
+		delete(c.entries, key)
+		c.lru.Remove(e)
+	}
+}
+
+func (c *ChildInjectorCache) evictIdlePeriodically(stop chan struct{}) {
+	ticker := time.NewTicker(c.IdleTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.mu.Lock()
+			if !c.closed {
+				c.evictIdle()
+			}
+			c.mu.Unlock()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (c *ChildInjectorCache) now() time.Time {
+	if c.Clock == nil {
+		return systemClock{}.Now()
+	}
+	return c.Clock.Now()
+}
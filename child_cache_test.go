@@ -0,0 +1,198 @@
+package inject
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type tenantID string
+
+type tenantDB struct {
+	tenant tenantID
+	rec    *tenantRecorder
+}
+
+func (db *tenantDB) Close() error {
+	db.rec.add("close " + string(db.tenant))
+	return nil
+}
+
+type tenantRecorder struct {
+	mu      sync.Mutex
+	events  []string
+	created map[string]int
+}
+
+func (r *tenantRecorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *tenantRecorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := append([]string(nil), r.events...)
+	sort.Strings(events)
+	return events
+}
+
+// newTenantCache creates a cache of child injectors with a tenantDB singleton.
+// Creation blocks until the given channel is closed, if not nil.
+func newTenantCache(t *testing.T, rec *tenantRecorder, block chan struct{}) *ChildInjectorCache {
+	parent, err := NewInjector()
+	require.NoError(t, err)
+	return &ChildInjectorCache{
+		Parent: parent,
+		Modules: func(key string) ([]Module, error) {
+			if block != nil {
+				<-block
+			}
+			rec.mu.Lock()
+			rec.created[key]++
+			rec.mu.Unlock()
+			if key == "invalid" {
+				return nil, errors.New("unknown tenant")
+			}
+			m := NewModule()
+			m.Bind(tenantID("")).ToSingleton(tenantID(key))
+			m.BindSingletonConstructor(func(tenant tenantID) *tenantDB {
+				return &tenantDB{tenant, rec}
+			}).Eagerly()
+			return []Module{m}, nil
+		},
+	}
+}
+
+func TestChildInjectorCache(t *testing.T) {
+	rec := &tenantRecorder{created: map[string]int{}}
+	cache := newTenantCache(t, rec, nil)
+	cache.MaxSize = 2
+
+	a, err := cache.Get(context.Background(), "a")
+	require.NoError(t, err)
+	aAgain, err := cache.Get(context.Background(), "a")
+	require.NoError(t, err)
+	require.True(t, a == aAgain)
+	db, err := a.Get(&tenantDB{})
+	require.NoError(t, err)
+	require.Equal(t, tenantID("a"), db.(*tenantDB).tenant)
+
+	_, err = cache.Get(context.Background(), "b")
+	require.NoError(t, err)
+	_, err = cache.Get(context.Background(), "a")
+	require.NoError(t, err)
+	// evicts b, the least recently used child injector
+	_, err = cache.Get(context.Background(), "c")
+	require.NoError(t, err)
+	require.Equal(t, 2, cache.Len())
+	waitFor(t, func() bool { return len(rec.get()) == 1 })
+	require.Equal(t, []string{"close b"}, rec.get())
+
+	_, err = cache.Get(context.Background(), "b")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"a": 1, "b": 2, "c": 1}, rec.created)
+
+	_, err = cache.Get(context.Background(), "invalid")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown tenant")
+	// failed creations are not cached
+	_, err = cache.Get(context.Background(), "invalid")
+	require.Error(t, err)
+	require.Equal(t, 2, rec.created["invalid"])
+
+	require.NoError(t, cache.Close(context.Background()))
+	require.Equal(t, []string{"close a", "close b", "close b", "close c"}, rec.get())
+	_, err = cache.Get(context.Background(), "a")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeCacheClosed)
+}
+
+func TestChildInjectorCacheIdleTimeout(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	rec := &tenantRecorder{created: map[string]int{}}
+	evicted := make(chan string, 10)
+	cache := newTenantCache(t, rec, nil)
+	cache.IdleTimeout = time.Hour
+	cache.Clock = clock
+	cache.OnEvict = func(key string, err error) {
+		require.NoError(t, err)
+		evicted <- key
+	}
+	defer cache.Close(context.Background())
+
+	_, err := cache.Get(context.Background(), "a")
+	require.NoError(t, err)
+	clock.advance(30 * time.Minute)
+	_, err = cache.Get(context.Background(), "b")
+	require.NoError(t, err)
+	clock.advance(45 * time.Minute)
+	_, err = cache.Get(context.Background(), "b")
+	require.NoError(t, err)
+
+	require.Equal(t, "a", <-evicted)
+	require.Equal(t, []string{"close a"}, rec.get())
+	require.Equal(t, 1, cache.Len())
+}
+
+func TestChildInjectorCacheEvictsChildInUse(t *testing.T) {
+	rec := &tenantRecorder{created: map[string]int{}}
+	evicted := make(chan string, 10)
+	cache := newTenantCache(t, rec, nil)
+	cache.MaxSize = 1
+	cache.OnEvict = func(key string, err error) {
+		require.NoError(t, err)
+		evicted <- key
+	}
+	defer cache.Close(context.Background())
+
+	// a is still in use when it is evicted
+	a, err := cache.Get(context.Background(), "a")
+	require.NoError(t, err)
+	_, err = cache.Get(context.Background(), "b")
+	require.NoError(t, err)
+
+	require.Equal(t, "a", <-evicted)
+	require.Equal(t, []string{"close a"}, rec.get())
+	_, err = a.Get(&tenantDB{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeInjectorClosed)
+}
+
+func TestChildInjectorCacheConcurrentCreation(t *testing.T) {
+	rec := &tenantRecorder{created: map[string]int{}}
+	block := make(chan struct{})
+	cache := newTenantCache(t, rec, block)
+	defer cache.Close(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := cache.Get(ctx, "a")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeContextDone)
+
+	var wg sync.WaitGroup
+	injectors := make([]Injector, 10)
+	for i := range injectors {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			injector, err := cache.Get(context.Background(), "a")
+			require.NoError(t, err)
+			injectors[i] = injector
+		}(i)
+	}
+	close(block)
+	wg.Wait()
+
+	for _, injector := range injectors {
+		require.True(t, injector == injectors[0])
+	}
+	require.Equal(t, 1, rec.created["a"])
+}
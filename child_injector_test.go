@@ -1,6 +1,7 @@
 package inject_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -145,3 +146,195 @@ func TestChildInjectorWithOverrides(t *testing.T) {
 		require.Equal(t, rsOverridden, dob.Restricted)
 	})
 }
+
+type closeRecorder struct {
+	name   string
+	closed *[]string
+}
+
+func (c *closeRecorder) Close() error {
+	*c.closed = append(*c.closed, c.name)
+	return nil
+}
+
+type parentResource struct{ *closeRecorder }
+
+type childResource struct{ *closeRecorder }
+
+func TestCloseChildInjector(t *testing.T) {
+	var closed []string
+	gm := inject.NewModule()
+	gm.BindSingletonConstructor(func() *parentResource {
+		return &parentResource{&closeRecorder{"parent", &closed}}
+	})
+	ginj, err := inject.NewInjector(gm)
+	require.NoError(t, err)
+
+	rm := inject.NewModule()
+	rm.BindSingletonConstructor(func(p *parentResource) *childResource {
+		return &childResource{&closeRecorder{"child", &closed}}
+	})
+	rinj, err := ginj.NewChildInjector(nil, rm)
+	require.NoError(t, err)
+
+	_, err = rinj.Get(&childResource{})
+	require.NoError(t, err)
+
+	require.NoError(t, rinj.Close(context.Background()))
+	require.Equal(t, []string{"child"}, closed)
+
+	t.Run("closed child refuses get", func(t *testing.T) {
+		_, err := rinj.Get(&childResource{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Injector closed")
+		_, err = rinj.Get(&parentResource{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Injector closed")
+		_, err = rinj.NewChildInjector(nil)
+		require.Error(t, err)
+	})
+
+	t.Run("parent still usable", func(t *testing.T) {
+		_, err := ginj.Get(&parentResource{})
+		require.NoError(t, err)
+	})
+
+	require.NoError(t, ginj.Close(context.Background()))
+	require.Equal(t, []string{"child", "parent"}, closed)
+}
+
+func TestCloseParentClosesChildInjectors(t *testing.T) {
+	var closed []string
+	gm := inject.NewModule()
+	gm.BindSingletonConstructor(func() *parentResource {
+		return &parentResource{&closeRecorder{"parent", &closed}}
+	})
+	ginj, err := inject.NewInjector(gm)
+	require.NoError(t, err)
+
+	rm := inject.NewModule()
+	rm.BindSingletonConstructor(func(p *parentResource) *childResource {
+		return &childResource{&closeRecorder{"child", &closed}}
+	})
+	rinj, err := ginj.NewChildInjector(nil, rm)
+	require.NoError(t, err)
+	_, err = rinj.Get(&childResource{})
+	require.NoError(t, err)
+
+	require.NoError(t, ginj.Close(context.Background()))
+	require.Equal(t, []string{"child", "parent"}, closed)
+
+	_, err = rinj.Get(&childResource{})
+	require.Error(t, err)
+	_, err = ginj.NewChildInjector(nil, inject.NewModule())
+	require.Error(t, err)
+}
+
+type ServiceName string
+
+type ServiceLogger struct {
+	*closeRecorder
+	Service ServiceName
+}
+
+type DependsOnLogger struct {
+	Logger *ServiceLogger
+}
+
+func TestPerChildInjectorSingleton(t *testing.T) {
+	var closed []string
+	created := 0
+	gm := inject.NewModule()
+	gm.BindSingletonConstructor(func(name ServiceName) *ServiceLogger {
+		created++
+		return &ServiceLogger{&closeRecorder{"logger " + string(name), &closed}, name}
+	}).PerChildInjector()
+	ginj, err := inject.NewInjector(gm)
+	require.NoError(t, err)
+
+	_, err = ginj.Get(&ServiceLogger{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Singleton is only available in child injectors")
+
+	newChild := func(name ServiceName) inject.Injector {
+		m := inject.NewModule()
+		m.Bind(ServiceName("")).ToSingleton(name)
+		inj, err := ginj.NewChildInjector(nil, m)
+		require.NoError(t, err)
+		return inj
+	}
+	payment := newChild("payment")
+	inventory := newChild("inventory")
+	require.Equal(t, 0, created)
+
+	p1, err := payment.Get(&ServiceLogger{})
+	require.NoError(t, err)
+	p2, err := payment.Get(&ServiceLogger{})
+	require.NoError(t, err)
+	i1, err := inventory.Get(&ServiceLogger{})
+	require.NoError(t, err)
+	require.True(t, p1 == p2)
+	require.Equal(t, ServiceName("payment"), p1.(*ServiceLogger).Service)
+	require.Equal(t, ServiceName("inventory"), i1.(*ServiceLogger).Service)
+	require.Equal(t, 2, created)
+
+	// children of a child share the instance of the child
+	grandchild, err := payment.NewChildInjector(nil)
+	require.NoError(t, err)
+	var dol DependsOnLogger
+	require.NoError(t, grandchild.Populate(&dol))
+	require.True(t, p1 == dol.Logger)
+
+	// the instance is stopped with its child injector
+	require.NoError(t, payment.Close(context.Background()))
+	require.Equal(t, []string{"logger payment"}, closed)
+	require.NoError(t, ginj.Close(context.Background()))
+	require.Equal(t, []string{"logger payment", "logger inventory"}, closed)
+}
+
+func TestPerChildInjectorSingletonErrors(t *testing.T) {
+	gm := inject.NewModule()
+	gm.BindSingletonConstructor(func(name ServiceName) *ServiceLogger {
+		return &ServiceLogger{Service: name}
+	}).PerChildInjector()
+	ginj, err := inject.NewInjector(gm)
+	require.NoError(t, err)
+
+	t.Run("missing dependency in child", func(t *testing.T) {
+		_, err := ginj.NewChildInjector(nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "No binding for binding key")
+		require.Contains(t, err.Error(), "bindingKey:{type:inject_test.ServiceName}")
+	})
+
+	t.Run("redefinition in child", func(t *testing.T) {
+		m := inject.NewModule()
+		m.Bind(ServiceName("")).ToSingleton(ServiceName("payment"))
+		m.BindSingleton(&ServiceLogger{})
+		_, err := ginj.NewChildInjector(nil, m)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Already found a binding for this binding key")
+	})
+}
+
+func TestPerChildInjectorEagerSingleton(t *testing.T) {
+	created := 0
+	gm := inject.NewModule()
+	gm.BindSingletonConstructor(func(name ServiceName) *ServiceLogger {
+		created++
+		return &ServiceLogger{Service: name}
+	}).PerChildInjector().Eagerly()
+	ginj, err := inject.NewInjector(gm)
+	require.NoError(t, err)
+	require.Equal(t, 0, created)
+
+	m := inject.NewModule()
+	m.Bind(ServiceName("")).ToSingleton(ServiceName("payment"))
+	_, err = ginj.NewChildInjector(nil, m)
+	require.NoError(t, err)
+	require.Equal(t, 1, created)
+
+	tree, err := ginj.DependencyTree()
+	require.NoError(t, err)
+	require.Contains(t, tree.String(), "{type:*inject_test.ServiceLogger} : per child singleton <")
+}
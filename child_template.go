@@ -0,0 +1,114 @@
+package inject
+
+import (
+	"context"
+)
+
+// ChildInjectorTemplate creates child injectors for a fixed set of child
+// modules. The modules are installed and validated once, when the template is
+// created, so that creating a child injector from the template merely binds
+// the prepared bindings to the new child. See
+// Injector.NewChildInjectorTemplate.
+type ChildInjectorTemplate interface {
+	// NewNamedChildInjector creates a child injector with the given name.
+	// Each child injector has its own instances of the singletons of the
+	// child modules. Eager singletons are created as with
+	// Injector.NewNamedChildInjector.
+	NewNamedChildInjector(name string) (Injector, error)
+
+	// NewChildInjector calls NewNamedChildInjector with the caller's code
+	// location as name.
+	NewChildInjector() (Injector, error)
+}
+
+// childInjectorTemplate is a set of child modules that have been installed and
+// validated against a parent injector.
+type childInjectorTemplate struct {
+	parent *injector
+	// the bindings of the child modules
+	bindings []templateBinding
+	// the eager singletons to create in each child injector
+	eager []*singletonBuilder
+	// the eager per-child singletons of the child modules
+	perChildEager []*singletonBuilder
+	// whether the child modules make the child injectors reject nil results
+	rejectNilResults bool
+}
+
+// templateBinding is a binding of a child module, which is resolved against
+// each child injector created from the template.
+type templateBinding struct {
+	key     bindingKey
+	binding binding
+	module  *module
+}
+
+func (inj *injector) NewChildInjectorTemplate(overridesType interface{}, modules ...Module) (ChildInjectorTemplate, error) {
+	if err := inj.verifyNotClosed(); err != nil {
+		return nil, err
+	}
+	modules = inj.childModules(overridesType, modules)
+
+	// install and validate the modules in a prototype child injector that
+	// is never used otherwise
+	prototype := inj.newChild(callerName(3, "template"))
+	eager, err := prototype.install(modules)
+	if err != nil {
+		return nil, err
+	}
+	if err := prototype.validate(newCtx(context.Background(), prototype)); err != nil {
+		return nil, err
+	}
+
+	t := &childInjectorTemplate{
+		parent:           inj,
+		eager:            eager,
+		perChildEager:    prototype.perChildEager,
+		rejectNilResults: prototype.rejectNilResults,
+	}
+	multi := multibindings{}
+	for _, m := range modules {
+		// the module type has been verified in install
+		castModule := m.(*module)
+		for key, binding := range castModule.bindings {
+			if !multi.add(key, binding, castModule) {
+				t.bindings = append(t.bindings, templateBinding{key, binding, castModule})
+			}
+		}
+	}
+	for key, multiBinding := range multi {
+		t.bindings = append(t.bindings, templateBinding{key, multiBinding, nil})
+	}
+	return t, nil
+}
+
+func (t *childInjectorTemplate) NewChildInjector() (Injector, error) {
+	return t.NewNamedChildInjector(callerName(3, "child"))
+}
+
+func (t *childInjectorTemplate) NewNamedChildInjector(name string) (Injector, error) {
+	if err := t.parent.verifyNotClosed(); err != nil {
+		return nil, err
+	}
+	child := t.parent.newChild(name)
+	child.rejectNilResults = t.rejectNilResults
+	for _, b := range t.bindings {
+		if err := child.installBinding(b.module, b.key, b.binding); err != nil {
+			return nil, err
+		}
+	}
+	if err := child.installModule(child.createInjectorModule().(*module), nil); err != nil {
+		return nil, err
+	}
+	child.resolvePerChildBindings()
+	child.perChildEager = t.perChildEager
+
+	if err := child.createEager(context.Background(), t.eager); err != nil {
+		return nil, err
+	}
+	if err := t.parent.verifyNotClosed(); err != nil {
+		_ = child.Close(context.Background())
+		return nil, err
+	}
+	return child, nil
+}
@@ -0,0 +1,181 @@
+package inject_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/eluv-io/inject-go"
+)
+
+type TenantID string
+
+type TenantStore struct {
+	*closeRecorder
+	Tenant TenantID
+	Global GlobalScope
+}
+
+type TenantResource interface {
+	tenantResource()
+}
+
+func (*TenantStore) tenantResource() {}
+
+func newTenantModule(closed *[]string) inject.Module {
+	m := inject.NewModule()
+	m.BindSingletonConstructor(func(global GlobalScope, id TenantID) *TenantStore {
+		return &TenantStore{&closeRecorder{"store " + string(id), closed}, id, global}
+	})
+	return m
+}
+
+func TestChildInjectorTemplate(t *testing.T) {
+	var closed []string
+	gm := inject.NewModule()
+	gm.Bind(GlobalScope("")).ToSingleton(gs)
+	// a per-child singleton of the parent, identifying each child
+	nextID := 0
+	gm.BindSingletonConstructor(func() TenantID {
+		nextID++
+		return TenantID(fmt.Sprint("tenant", nextID))
+	}).PerChildInjector()
+	ginj, err := inject.NewInjector(gm)
+	require.NoError(t, err)
+
+	template, err := ginj.NewChildInjectorTemplate(nil, newTenantModule(&closed))
+	require.NoError(t, err)
+
+	t1, err := template.NewNamedChildInjector("tenant1")
+	require.NoError(t, err)
+	t2, err := template.NewNamedChildInjector("tenant2")
+	require.NoError(t, err)
+	require.Equal(t, "injector{tenant1}, parent "+ginj.String(), t1.String())
+
+	s1, err := t1.Get(&TenantStore{})
+	require.NoError(t, err)
+	s1Again, err := t1.Get(&TenantStore{})
+	require.NoError(t, err)
+	s2, err := t2.Get(&TenantStore{})
+	require.NoError(t, err)
+	require.True(t, s1 == s1Again)
+	require.Equal(t, TenantID("tenant1"), s1.(*TenantStore).Tenant)
+	require.Equal(t, TenantID("tenant2"), s2.(*TenantStore).Tenant)
+	require.Equal(t, gs, s1.(*TenantStore).Global)
+
+	// each child has its own injector binding
+	i1, err := t1.Get((*inject.Injector)(nil))
+	require.NoError(t, err)
+	require.Equal(t, t1, i1)
+
+	require.NoError(t, t1.Close(context.Background()))
+	require.Equal(t, []string{"store tenant1"}, closed)
+	require.NoError(t, ginj.Close(context.Background()))
+	require.Equal(t, []string{"store tenant1", "store tenant2"}, closed)
+
+	_, err = template.NewChildInjector()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Injector closed")
+}
+
+func TestChildInjectorTemplateValidation(t *testing.T) {
+	ginj, err := inject.NewInjector()
+	require.NoError(t, err)
+
+	m := inject.NewModule()
+	m.BindSingletonConstructor(func(global GlobalScope) *TenantStore {
+		return &TenantStore{Global: global}
+	})
+	_, err = ginj.NewChildInjectorTemplate(nil, m)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "No binding for binding key")
+
+	m = inject.NewModule()
+	m.Bind(GlobalScope("")).ToSingleton(gs)
+	m.Bind(GlobalScope("")).ToSingleton(gs)
+	_, err = ginj.NewChildInjectorTemplate(nil, m)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Errors with bindings")
+}
+
+func TestChildInjectorTemplateEager(t *testing.T) {
+	ginj, err := inject.NewInjector()
+	require.NoError(t, err)
+
+	created := 0
+	fail := false
+	m := inject.NewModule()
+	m.Bind(GlobalScope("")).ToSingleton(gs)
+	m.BindSingletonConstructor(func(global GlobalScope) (*TenantStore, error) {
+		created++
+		if fail {
+			return nil, errors.New("tenant store unavailable")
+		}
+		return &TenantStore{Global: global}, nil
+	}).Eagerly()
+	m.BindInterface((*TenantResource)(nil)).To(&TenantStore{})
+
+	template, err := ginj.NewChildInjectorTemplate(nil, m)
+	require.NoError(t, err)
+	require.Equal(t, 0, created)
+
+	child, err := template.NewChildInjector()
+	require.NoError(t, err)
+	require.Equal(t, 1, created)
+	store, err := child.Get((*TenantResource)(nil))
+	require.NoError(t, err)
+	require.Equal(t, gs, store.(*TenantStore).Global)
+
+	fail = true
+	_, err = template.NewChildInjector()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "tenant store unavailable")
+}
+
+func TestChildInjectorTemplateWithOverrides(t *testing.T) {
+	overrides := inject.NewModule()
+	overrides.Bind(RestrictedScope("")).ToSingleton(rsOverridden)
+
+	gm := inject.NewModule()
+	gm.Bind(GlobalScope("")).ToSingleton(gs)
+	gm.Bind((*testChildInjectorOverrides)(nil)).ToSingleton(overrides)
+	ginj, err := inject.NewInjector(gm)
+	require.NoError(t, err)
+
+	rm := inject.NewModule()
+	rm.Bind(RestrictedScope("")).ToSingleton(rs)
+	template, err := ginj.NewChildInjectorTemplate((*testChildInjectorOverrides)(nil), rm)
+	require.NoError(t, err)
+	child, err := template.NewChildInjector()
+	require.NoError(t, err)
+
+	var dob DependsOnBoth
+	require.NoError(t, child.Populate(&dob))
+	require.Equal(t, gs, dob.Global)
+	require.Equal(t, rsOverridden, dob.Restricted)
+}
+
+func TestChildInjectorTemplateRejectNilResults(t *testing.T) {
+	ginj, err := inject.NewInjector(inject.NewModule())
+	require.NoError(t, err)
+
+	// the child modules make the children reject nil results
+	m := inject.NewModule()
+	m.BindConstructor(func() *TenantStore { return nil })
+	template, err := ginj.NewChildInjectorTemplate(nil, m, inject.RejectNilResults())
+	require.NoError(t, err)
+	child, err := template.NewChildInjector()
+	require.NoError(t, err)
+
+	_, err = child.Get((*TenantStore)(nil))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Constructor returned nil")
+
+	// the parent is not affected
+	_, err = ginj.Get((*TenantStore)(nil))
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "Constructor returned nil")
+}
@@ -81,10 +81,7 @@ func verifyIsFunc(funcReflectType reflect.Type) error {
 	}
 	numIn := funcReflectType.NumIn()
 	for i := 0; i < numIn; i++ {
-		parameterReflectType := funcReflectType.In(i)
-		if isInterface(parameterReflectType) {
-			parameterReflectType = reflect.PtrTo(parameterReflectType)
-		}
+		parameterReflectType, _ := parameterKeyReflectType(funcReflectType.In(i))
 		if err := verifyParameterCanBeInjected(parameterReflectType, ""); err != nil {
 			return err
 		}
@@ -112,10 +109,20 @@ func verifyIsTaggedFunc(funcReflectType reflect.Type) error {
 func verifyStructCanBePopulated(structReflectType reflect.Type) error {
 	numFields := structReflectType.NumField()
 	for i := 0; i < numFields; i++ {
-		structFieldReflectType, tag := getStructFieldReflectTypeAndTag(structReflectType.Field(i))
-		if err := verifyParameterCanBeInjected(structFieldReflectType, tag); err != nil {
+		structField := structReflectType.Field(i)
+		tag, err := getStructFieldTag(structField)
+		if err != nil {
+			return err.withTag("field", structField.Name)
+		}
+		structFieldReflectType, _ := parameterKeyReflectType(structField.Type)
+		if err := verifyParameterCanBeInjected(structFieldReflectType, tag.name); err != nil {
 			return err
 		}
+		if tag.hasDefault {
+			if _, err := parseDefaultValue(structFieldReflectType, tag.defaultValue); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -134,11 +141,7 @@ func getParameterBindingKeysForFunc(funcReflectType reflect.Type) []bindingKey {
 	numIn := funcReflectType.NumIn()
 	bindingKeys := make([]bindingKey, numIn)
 	for i := 0; i < numIn; i++ {
-		inReflectType := funcReflectType.In(i)
-		if inReflectType.Kind() == reflect.Interface {
-			inReflectType = reflect.PtrTo(inReflectType)
-		}
-		bindingKeys[i] = newBindingKey(inReflectType)
+		bindingKeys[i] = newParameterBindingKey(funcReflectType.In(i), injectTag{})
 	}
 	return bindingKeys
 }
@@ -151,22 +154,16 @@ func getStructFieldBindingKeys(structReflectType reflect.Type) []bindingKey {
 	numFields := structReflectType.NumField()
 	bindingKeys := make([]bindingKey, numFields)
 	for i := 0; i < numFields; i++ {
-		structFieldReflectType, tag := getStructFieldReflectTypeAndTag(structReflectType.Field(i))
-		if tag != "" {
-			bindingKeys[i] = newTaggedBindingKey(structFieldReflectType, tag)
-		} else {
-			bindingKeys[i] = newBindingKey(structFieldReflectType)
-		}
+		structField := structReflectType.Field(i)
+		// verified by verifyStructCanBePopulated
+		tag, _ := getStructFieldTag(structField)
+		bindingKeys[i] = newParameterBindingKey(structField.Type, tag)
 	}
 	return bindingKeys
 }
 
-func getStructFieldReflectTypeAndTag(structField reflect.StructField) (reflect.Type, string) {
-	structFieldReflectType := structField.Type
-	if structFieldReflectType.Kind() == reflect.Interface {
-		structFieldReflectType = reflect.PtrTo(structFieldReflectType)
-	}
-	return structFieldReflectType, structField.Tag.Get(taggedFuncStructFieldTag)
+func getStructFieldTag(structField reflect.StructField) (injectTag, *injectError) {
+	return parseInjectTag(structField.Tag.Get(taggedFuncStructFieldTag))
 }
 
 func getTaggedFuncStructReflectValue(structReflectType reflect.Type, reflectValues []reflect.Value) *reflect.Value {
@@ -1,25 +1,78 @@
 package inject
 
 import (
+	"context"
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // ctx is the dependency resolution context. It is used to detect circular
-// dependencies and provide the dependency tree.
+// dependencies and provide the dependency tree. It also carries the
+// context.Context of the caller, which is injected into constructors and
+// functions that declare a parameter of that type.
 type ctx struct {
 	root    *stack
 	current *stack
+	context context.Context
+	// the lifecycle for the cleanup functions of the constructor that is
+	// being called, if it is not the lifecycle of the injector
+	lifecycle *lifecycle
+	// the call of the constructor or function whose arguments are being
+	// resolved, if any
+	call *resolution
 }
 
-func newCtx(inj *injector) ctx {
+func newCtx(c context.Context, inj *injector) ctx {
 	itype := "root"
 	if inj.parent != nil {
 		itype = "child"
 	}
 	root := newStack(nil, rootBindingKey{itype}, rootBinding{inj.name})
-	return ctx{root: root, current: root}
+	return ctx{root: root, current: root, context: c}
+}
+
+// newRuntimeCtx creates the resolution context for a request of the injector
+// at runtime. If the injector is the view of a constructor or function call
+// that is still running, i.e. the request is made through the Injector that was
+// injected into the call, the resolution continues on the path of that call.
+// This detects circular dependencies that are not visible to static validation,
+// which would otherwise deadlock or recurse endlessly.
+func newRuntimeCtx(c context.Context, inj *injector) ctx {
+	ctx, ok := inj.call.active()
+	if !ok {
+		return newCtx(c, inj)
+	}
+	ctx.context = c
+	return ctx
+}
+
+// startCall marks the start of the call of a constructor or function on the
+// current path of this context. The returned function must be called when the
+// call has ended.
+func (c *ctx) startCall() (end func()) {
+	c.call = &resolution{}
+	c.call.ctx = *c
+	return c.call.end
+}
+
+// lifecycleOf returns the lifecycle for the cleanup functions of the
+// constructor that is being called by the given injector.
+func (c *ctx) lifecycleOf(inj *injector) *lifecycle {
+	if c.lifecycle != nil {
+		return c.lifecycle
+	}
+	return &inj.lifecycle
+}
+
+// verifyNotDone returns an error if the caller's context is done.
+func (c *ctx) verifyNotDone() error {
+	if err := c.context.Err(); err != nil {
+		return errContextDone.withTag("err", err).withTag("path", "\n"+c.current.IndentString("\t* "))
+	}
+	return nil
 }
 
 func (c *ctx) push(key bindingKey, binding resolvedBinding) (err error) {
@@ -39,7 +92,10 @@ func (c *ctx) tree() DependencyTree {
 ////////////////////////////////////////////////////////////////////////////////
 
 type stack struct {
-	parent   *stack
+	parent *stack
+	// mu protects the children, which may be added concurrently by the
+	// requests of an injected Injector
+	mu       sync.Mutex
 	children []*stack
 	key      bindingKey
 	binding  resolvedBinding
@@ -66,7 +122,9 @@ func (s *stack) push(key bindingKey, binding resolvedBinding) (*stack, error) {
 		}
 	}
 	child := newStack(s, key, binding)
+	s.mu.Lock()
 	s.children = append(s.children, child)
+	s.mu.Unlock()
 	return child, nil
 }
 
@@ -119,12 +177,14 @@ func (s *stack) print(sb *strings.Builder, ident, identSub string) {
 	sb.WriteString(s.binding.String())
 	sb.WriteString("\n")
 
+	s.mu.Lock()
 	sorted := make([]*stack, len(s.children))
 	copy(sorted, s.children)
+	s.mu.Unlock()
 	sort.Slice(sorted, func(i, j int) bool {
 		return sorted[i].String() < sorted[j].String()
 	})
-	last := len(s.children) - 1
+	last := len(sorted) - 1
 	for idx, child := range sorted {
 		if idx == last {
 			child.print(sb, identSub+identEnd, identSub+identSubEnd)
@@ -158,7 +218,7 @@ func (r rootBinding) validate(c ctx) error {
 	return nil
 }
 
-func (r rootBinding) get() (interface{}, error) {
+func (r rootBinding) get(ctx) (interface{}, error) {
 	return nil, errIntermediateBinding
 }
 
@@ -168,6 +228,48 @@ func (r rootBinding) String() string {
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// resolution is the resolution context of a running constructor or function
+// call, or of a request that invoked the provider of a scope. It is only valid
+// until it has ended.
+type resolution struct {
+	ctx   ctx
+	ended int32
+}
+
+func (r *resolution) end() {
+	atomic.StoreInt32(&r.ended, 1)
+}
+
+// active returns the resolution context if it has not ended yet.
+func (r *resolution) active() (ctx, bool) {
+	if r == nil || atomic.LoadInt32(&r.ended) != 0 {
+		return ctx{}, false
+	}
+	return r.ctx, true
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+var contextBindingKey = newBindingKey(reflect.TypeOf((*context.Context)(nil)))
+
+// contextBinding provides the caller's context.Context. It is the implicit
+// binding of context.Context, unless that is bound explicitly.
+type contextBinding struct{}
+
+func (c contextBinding) validate(ctx) error {
+	return nil
+}
+
+func (c contextBinding) get(ctx ctx) (interface{}, error) {
+	return ctx.context, nil
+}
+
+func (c contextBinding) String() string {
+	return "context"
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
 type DependencyTree interface {
 	String() string
 }
@@ -0,0 +1,144 @@
+package inject
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ContextScope is a scope whose instances live in a context.Context, e.g. the
+// context of a request. The scope is entered with EnterScope, which returns a
+// derived context. All requests of the injector with that context (see
+// Injector.GetContext) or a context derived from it share the instances of
+// bindings in the ContextScope. ExitScope releases them again:
+//
+//	ctx = inject.EnterScope(ctx)
+//	defer inject.ExitScope(ctx)
+//	handler, err := injector.GetContext(ctx, (*Handler)(nil))
+//
+// Requesting an instance of a binding in the ContextScope without having
+// entered the scope, or after exiting it, fails with an error.
+var ContextScope Scope = contextScope{}
+
+type contextScopeKey struct{}
+
+// contextScopeStore holds the instances of an entered context scope.
+type contextScopeStore struct {
+	// the instances and cleanup functions that need to be stopped on exit
+	lifecycle lifecycle
+
+	// mu protects the fields below
+	mu      sync.Mutex
+	entries map[*contextScopeProvider]*contextScopeEntry
+	exited  bool
+}
+
+type contextScopeEntry struct {
+	once  sync.Once
+	value interface{}
+	err   error
+}
+
+// EnterScope enters a new ContextScope and returns the context that holds its
+// instances.
+func EnterScope(ctx context.Context) context.Context {
+	store := &contextScopeStore{entries: make(map[*contextScopeProvider]*contextScopeEntry)}
+	return context.WithValue(ctx, contextScopeKey{}, store)
+}
+
+// ExitScope exits the ContextScope entered with EnterScope and stops its
+// instances that implement Stopper or io.Closer, and calls the cleanup
+// functions returned by their constructors. As with Injector.Close, instances
+// are stopped in reverse creation order.
+//
+// The instances are stopped even if the given context is done, e.g. because
+// the client of a request disconnected: they receive a context with the
+// values of the given context, but without its deadline and cancellation.
+func ExitScope(ctx context.Context) error {
+	store, ok := ctx.Value(contextScopeKey{}).(*contextScopeStore)
+	if !ok {
+		return errNotInScope.withTag("scope", ContextScope)
+	}
+	store.mu.Lock()
+	store.exited = true
+	store.entries = nil
+	store.mu.Unlock()
+	return store.lifecycle.close(detachedContext{ctx})
+}
+
+// detachedContext carries the values of its parent context, but neither its
+// deadline nor its cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+type contextScope struct{}
+
+func (c contextScope) String() string {
+	return "context scope"
+}
+
+func (c contextScope) ownsCleanups() {}
+
+func (c contextScope) Scope(key fmt.Stringer, unscoped Provider) Provider {
+	p := &contextScopeProvider{key: key, unscoped: unscoped}
+	return p.get
+}
+
+// contextScopeProvider provides the instances of a single binding in the
+// ContextScope.
+type contextScopeProvider struct {
+	key      fmt.Stringer
+	unscoped Provider
+}
+
+func (p *contextScopeProvider) get(ctx context.Context) (interface{}, error) {
+	store, ok := ctx.Value(contextScopeKey{}).(*contextScopeStore)
+	if !ok {
+		return nil, errNotInScope.withTag("scope", ContextScope).withTag("bindingKey", p.key)
+	}
+	entry, err := store.entry(p)
+	if err != nil {
+		return nil, unwrap(err).withTag("bindingKey", p.key)
+	}
+	entry.once.Do(func() {
+		ctx = context.WithValue(ctx, scopeLifecycleKey{}, &scopeLifecycle{p.key, &store.lifecycle})
+		entry.value, entry.err = p.unscoped(ctx)
+		if entry.err == nil {
+			store.lifecycle.track(p.key.String(), entry.value)
+		}
+	})
+	return entry.value, entry.err
+}
+
+// entry returns the entry of the given provider, creating it if necessary.
+func (s *contextScopeStore) entry(p *contextScopeProvider) (*contextScopeEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.exited {
+		return nil, errScopeExited.withTag("scope", ContextScope)
+	}
+	entry, ok := s.entries[p]
+	if !ok {
+		entry = &contextScopeEntry{}
+		s.entries[p] = entry
+	}
+	return entry, nil
+}
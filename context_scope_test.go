@@ -0,0 +1,97 @@
+package inject
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type scopedDB struct{}
+
+type scopedTx struct {
+	closed *[]string
+}
+
+func (t *scopedTx) Close() error {
+	*t.closed = append(*t.closed, "tx")
+	return nil
+}
+
+type scopedHandler struct {
+	tx *scopedTx
+}
+
+func newContextScopeModule(closed *[]string) Module {
+	module := NewModule()
+	module.BindSingletonConstructor(func() (*scopedDB, func()) {
+		return &scopedDB{}, func() { *closed = append(*closed, "db") }
+	})
+	module.BindConstructor(func(db *scopedDB) (*scopedTx, func()) {
+		return &scopedTx{closed}, func() { *closed = append(*closed, "tx cleanup") }
+	}).In(ContextScope)
+	module.BindConstructor(func(tx *scopedTx) *scopedHandler {
+		return &scopedHandler{tx}
+	})
+	return module
+}
+
+func TestContextScope(t *testing.T) {
+	var closed []string
+	injector, err := NewInjector(newContextScopeModule(&closed))
+	require.NoError(t, err)
+
+	ctx1 := EnterScope(context.Background())
+	ctx2 := EnterScope(context.Background())
+
+	h1, err := injector.GetContext(ctx1, &scopedHandler{})
+	require.NoError(t, err)
+	h2, err := injector.GetContext(context.WithValue(ctx1, ctxKey{}, "derived"), &scopedHandler{})
+	require.NoError(t, err)
+	h3, err := injector.GetContext(ctx2, &scopedHandler{})
+	require.NoError(t, err)
+	require.True(t, h1.(*scopedHandler).tx == h2.(*scopedHandler).tx)
+	require.True(t, h1.(*scopedHandler).tx != h3.(*scopedHandler).tx)
+
+	require.NoError(t, ExitScope(ctx1))
+	require.Equal(t, []string{"tx", "tx cleanup"}, closed)
+
+	_, err = injector.GetContext(ctx1, &scopedHandler{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeScopeExited)
+
+	require.NoError(t, ExitScope(ctx2))
+	require.NoError(t, injector.Close(context.Background()))
+	require.Equal(t, []string{"tx", "tx cleanup", "tx", "tx cleanup", "db"}, closed)
+}
+
+func TestContextScopeNotEntered(t *testing.T) {
+	var closed []string
+	injector, err := NewInjector(newContextScopeModule(&closed))
+	require.NoError(t, err)
+
+	_, err = injector.Get(&scopedHandler{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeNotInScope)
+	require.Contains(t, err.Error(), "bindingKey:{type:*inject.scopedTx}")
+
+	err = ExitScope(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeNotInScope)
+}
+
+func TestContextScopeExitWithDoneContext(t *testing.T) {
+	var closed []string
+	injector, err := NewInjector(newContextScopeModule(&closed))
+	require.NoError(t, err)
+
+	base, cancel := context.WithCancel(context.Background())
+	ctx := EnterScope(base)
+	_, err = injector.GetContext(ctx, &scopedHandler{})
+	require.NoError(t, err)
+
+	// e.g. the client of a request disconnected
+	cancel()
+	require.NoError(t, ExitScope(ctx))
+	require.Equal(t, []string{"tx", "tx cleanup"}, closed)
+}
@@ -0,0 +1,141 @@
+package inject
+
+import (
+	"reflect"
+)
+
+// defaultBinding is a binding declared with Module.BindDefault, which yields
+// to a regular binding of the same key. If modules that are installed into
+// each other bind both, the default binding records the regular binding as
+// its replacement.
+type defaultBinding struct {
+	binding
+	// the regular binding that replaces the default binding, if any
+	replacement binding
+}
+
+func (d *defaultBinding) String() string {
+	if d.replacement != nil {
+		return d.replacement.String() + " (replaces default " + d.binding.String() + ")"
+	}
+	return "default " + d.binding.String()
+}
+
+func (d *defaultBinding) resolvedBinding(module *module, injector *injector, key bindingKey) (resolvedBinding, error) {
+	def, err := d.binding.resolvedBinding(module, injector, key)
+	if err != nil {
+		return nil, err
+	}
+	resolved := &resolvedDefaultBinding{def: def}
+	if d.replacement != nil {
+		if resolved.replacement, err = d.replacement.resolvedBinding(module, injector, key); err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
+
+func (d *defaultBinding) rejectingNil() binding {
+	res := *d
+	if r, ok := d.binding.(nilRejecter); ok {
+		res.binding = r.rejectingNil()
+	}
+	if r, ok := d.replacement.(nilRejecter); ok {
+		res.replacement = r.rejectingNil()
+	}
+	return &res
+}
+
+// replaceDefault returns the binding of a key of a module that is bound to
+// both given bindings, if one of them is a default binding that has not been
+// replaced yet and the other one is a regular binding.
+func replaceDefault(found binding, other binding) (binding, bool) {
+	f, foundDefault := found.(*defaultBinding)
+	o, otherDefault := other.(*defaultBinding)
+	switch {
+	case foundDefault && f.replacement == nil && !otherDefault:
+		return &defaultBinding{f.binding, other}, true
+	case otherDefault && o.replacement == nil && !foundDefault:
+		return &defaultBinding{o.binding, found}, true
+	}
+	return nil, false
+}
+
+// resolvedDefaultBinding provides the value of the default binding of a key,
+// or of the regular binding that replaces it.
+type resolvedDefaultBinding struct {
+	def resolvedBinding
+	// the regular binding that replaces the default binding, if any
+	replacement resolvedBinding
+}
+
+func (r *resolvedDefaultBinding) String() string {
+	if r.replacement != nil {
+		return r.replacement.String() + " (replaces default " + r.def.String() + ")"
+	}
+	return "default " + r.def.String()
+}
+
+func (r *resolvedDefaultBinding) validate(ctx ctx) error {
+	return r.active().validate(ctx)
+}
+
+func (r *resolvedDefaultBinding) get(ctx ctx) (interface{}, error) {
+	return r.active().get(ctx)
+}
+
+// active returns the binding that provides the value.
+func (r *resolvedDefaultBinding) active() resolvedBinding {
+	if r.replacement != nil {
+		return r.replacement
+	}
+	return r.def
+}
+
+// replaceResolvedDefault works like replaceDefault for the resolved bindings
+// of an injector.
+func replaceResolvedDefault(found resolvedBinding, other resolvedBinding) (resolvedBinding, bool) {
+	f, foundDefault := found.(*resolvedDefaultBinding)
+	o, otherDefault := other.(*resolvedDefaultBinding)
+	switch {
+	case foundDefault && f.replacement == nil && !otherDefault:
+		return &resolvedDefaultBinding{f.def, other}, true
+	case otherDefault && o.replacement == nil && !foundDefault:
+		return &resolvedDefaultBinding{o.def, found}, true
+	}
+	return nil, false
+}
+
+// asPerChild returns the given binding as per-child singleton, if it is one.
+func asPerChild(binding resolvedBinding) (*perChildBinding, bool) {
+	if d, ok := binding.(*resolvedDefaultBinding); ok {
+		binding = d.active()
+	}
+	perChild, ok := binding.(*perChildBinding)
+	return perChild, ok
+}
+
+func (m *module) BindDefault(froms ...interface{}) Builder {
+	if !m.verifySupportedTypes(froms, isSupportedBindReflectType) {
+		return newNoOpBuilder()
+	}
+	return m.bindDefault(newBindingKey, froms)
+}
+
+func (m *module) BindTaggedDefault(tag string, froms ...interface{}) Builder {
+	if !m.verifyTag(tag) {
+		return newNoOpBuilder()
+	}
+	if !m.verifySupportedTypes(froms, isSupportedBindReflectType) {
+		return newNoOpBuilder()
+	}
+	return m.bindDefault(func(fromReflectType reflect.Type) bindingKey { return newTaggedBindingKey(fromReflectType, tag) }, froms)
+}
+
+func (m *module) bindDefault(newBindingKeyFunc func(reflect.Type) bindingKey, from []interface{}) Builder {
+	builder := m.bind(newBindingKeyFunc, from)
+	if b, ok := builder.(*baseBuilder); ok {
+		b.isDefault = true
+	}
+	return builder
+}
@@ -0,0 +1,128 @@
+package inject
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTracerLibrary returns a library module with a default tracer.
+func newTracerLibrary() Module {
+	m := NewModule()
+	m.BindDefault((*tracer)(nil)).ToSingleton(prefixTracer("default "))
+	m.BindTaggedDefault("name", "").ToSingleton("library")
+	return m
+}
+
+func getTracer(t *testing.T, injector Injector) string {
+	tr, err := injector.Get((*tracer)(nil))
+	require.NoError(t, err)
+	return tr.(tracer).trace("tracer")
+}
+
+func TestDefaultBinding(t *testing.T) {
+	t.Run("default used", func(t *testing.T) {
+		injector, err := NewInjector(newTracerLibrary())
+		require.NoError(t, err)
+		require.Equal(t, "default tracer", getTracer(t, injector))
+		name, err := injector.GetTaggedString("name")
+		require.NoError(t, err)
+		require.Equal(t, "library", name)
+
+		tree, err := injector.DependencyTree()
+		require.NoError(t, err)
+		require.Contains(t, tree.String(), "{type:*inject.tracer} : default singleton inject.prefixTracer")
+	})
+
+	for name, modules := range map[string]func(app Module) []Module{
+		"regular binding first": func(app Module) []Module {
+			return []Module{app, newTracerLibrary()}
+		},
+		"default binding first": func(app Module) []Module {
+			return []Module{newTracerLibrary(), app}
+		},
+		"installed module": func(app Module) []Module {
+			app.Install(newTracerLibrary())
+			return []Module{app}
+		},
+		"installing module": func(app Module) []Module {
+			lib := newTracerLibrary()
+			lib.Install(app)
+			return []Module{lib}
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			app := NewModule()
+			app.BindConstructor(func() tracer { return prefixTracer("app ") })
+			app.BindTaggedString("name").ToSingleton("app")
+			injector, err := NewInjector(modules(app)...)
+			require.NoError(t, err)
+			require.Equal(t, "app tracer", getTracer(t, injector))
+			name, err := injector.GetTaggedString("name")
+			require.NoError(t, err)
+			require.Equal(t, "app", name)
+
+			tree, err := injector.DependencyTree()
+			require.NoError(t, err)
+			require.Contains(t, tree.String(), "{type:string tag:name} : singleton string (replaces default singleton string)")
+		})
+	}
+}
+
+func TestDefaultBindingChildInjector(t *testing.T) {
+	injector, err := NewInjector(newTracerLibrary())
+	require.NoError(t, err)
+
+	child, err := injector.NewChildInjector(nil)
+	require.NoError(t, err)
+	require.Equal(t, "default tracer", getTracer(t, child))
+
+	for name, bindDefault := range map[string]bool{
+		"regular binding in child": false,
+		"default binding in child": true,
+	} {
+		t.Run(name, func(t *testing.T) {
+			cm := NewModule()
+			if bindDefault {
+				cm.BindDefault((*tracer)(nil)).ToSingleton(prefixTracer("child "))
+			} else {
+				cm.Bind((*tracer)(nil)).ToSingleton(prefixTracer("child "))
+			}
+			_, err := injector.NewChildInjector(nil, cm)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), injectErrorTypeAlreadyBound)
+			require.Contains(t, err.Error(), "scope:parent")
+			require.Equal(t, "default tracer", getTracer(t, injector))
+		})
+	}
+}
+
+func TestDefaultBindingErrors(t *testing.T) {
+	t.Run("two default bindings", func(t *testing.T) {
+		_, err := NewInjector(newTracerLibrary(), newTracerLibrary())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), injectErrorTypeAlreadyBound)
+	})
+
+	t.Run("two regular bindings", func(t *testing.T) {
+		a := NewModule()
+		a.Bind((*tracer)(nil)).ToSingleton(prefixTracer("a "))
+		b := NewModule()
+		b.Bind((*tracer)(nil)).ToSingleton(prefixTracer("b "))
+		_, err := NewInjector(a, newTracerLibrary(), b)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), injectErrorTypeAlreadyBound)
+	})
+
+	t.Run("replaced default not validated", func(t *testing.T) {
+		lib := NewModule()
+		lib.BindDefault((*tracer)(nil)).ToConstructor(func(config *routeConfig) tracer {
+			return prefixTracer(config.prefix)
+		})
+		app := NewModule()
+		app.Bind((*tracer)(nil)).ToSingleton(prefixTracer("app "))
+		injector, err := NewInjector(lib, app)
+		require.NoError(t, err)
+		require.Equal(t, "app tracer", getTracer(t, injector))
+	})
+}
@@ -0,0 +1,15 @@
+package global
+
+import (
+	"log"
+	"os"
+
+	"github.com/eluv-io/inject-go/example/hierarchical"
+)
+
+// newServiceLogger creates the logger of a service. It is bound per child
+// injector, so that each service gets its own logger with the name of the
+// service as prefix.
+func newServiceLogger(name hierarchical.ServiceName) *log.Logger {
+	return log.New(os.Stdout, "["+string(name)+"] ", 0)
+}
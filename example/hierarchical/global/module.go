@@ -2,6 +2,8 @@ package global
 
 import (
 	"github.com/eluv-io/inject-go"
+	"github.com/eluv-io/inject-go/example/hierarchical/inventory"
+	"github.com/eluv-io/inject-go/example/hierarchical/payment"
 )
 
 func NewModule() inject.Module {
@@ -10,5 +12,7 @@ func NewModule() inject.Module {
 	m.BindSingletonConstructor(newApp)
 	m.BindSingletonConstructor(newServicesFactory)
 	m.BindSingletonConstructor(newStore)
+	m.BindSingletonConstructor(newServiceLogger).PerChildInjector()
+	m.Install(payment.NewRegistryModule(), inventory.NewRegistryModule())
 	return m
 }
@@ -1,29 +1,25 @@
 package global
 
 import (
-	"errors"
 	"fmt"
 
 	"github.com/eluv-io/inject-go"
 	"github.com/eluv-io/inject-go/example/hierarchical"
-	"github.com/eluv-io/inject-go/example/hierarchical/inventory"
-	"github.com/eluv-io/inject-go/example/hierarchical/payment"
 )
 
-func newServicesFactory(inj inject.Injector) *ServicesFactory {
-	return &ServicesFactory{inj: inj}
+func newServicesFactory(inj inject.Injector, registry map[string]hierarchical.ServiceFactory) *ServicesFactory {
+	return &ServicesFactory{inj: inj, registry: registry}
 }
 
 type ServicesFactory struct {
-	inj inject.Injector // global injector
+	inj      inject.Injector                        // global injector
+	registry map[string]hierarchical.ServiceFactory // factories by service type
 }
 
 func (f *ServicesFactory) CreateService(sc ServiceConfig) (hierarchical.Service, error) {
-	switch sc.Type {
-	case "Payment":
-		return payment.CreateService(f.inj, sc.Config.(payment.Config))
-	case "Inventory":
-		return inventory.CreateService(f.inj, sc.Config.(inventory.Config))
+	create, ok := f.registry[sc.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown service type [%s]", sc.Type)
 	}
-	return nil, errors.New(fmt.Sprintf("unknown service type [%s]", sc.Type))
+	return create(f.inj, sc.Config)
 }
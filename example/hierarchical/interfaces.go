@@ -1,5 +1,7 @@
 package hierarchical
 
+import "github.com/eluv-io/inject-go"
+
 type Service interface {
 	Start()
 	Stop()
@@ -8,3 +10,12 @@ type Service interface {
 type Store interface {
 	StoreTransaction(tx string)
 }
+
+// ServiceFactory creates a service with the given configuration in a child
+// injector of the given parent injector. The factories of all service types
+// are registered in a map binding by service type.
+type ServiceFactory func(parent inject.Injector, config interface{}) (Service, error)
+
+// ServiceName is the name of a service, bound in the child injector of each
+// service.
+type ServiceName string
@@ -2,11 +2,23 @@ package inventory
 
 import (
 	"github.com/eluv-io/inject-go"
+	"github.com/eluv-io/inject-go/example/hierarchical"
 )
 
 func NewModule(conf Config) inject.Module {
 	m := inject.NewModule()
 	m.Bind(Config{}).ToSingleton(conf)
+	m.Bind(hierarchical.ServiceName("")).ToSingleton(hierarchical.ServiceName("inventory"))
 	m.BindSingletonConstructor(newService)
 	return m
 }
+
+// NewRegistryModule registers the inventory service in the services registry
+// of the global injector.
+func NewRegistryModule() inject.Module {
+	m := inject.NewModule()
+	m.BindMap(map[string]hierarchical.ServiceFactory(nil)).ToSingleton("Inventory", func(parent inject.Injector, config interface{}) (hierarchical.Service, error) {
+		return CreateService(parent, config.(Config))
+	})
+	return m
+}
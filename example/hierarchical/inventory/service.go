@@ -1,7 +1,7 @@
 package inventory
 
 import (
-	"fmt"
+	"log"
 
 	"github.com/eluv-io/inject-go"
 	"github.com/eluv-io/inject-go/example/hierarchical"
@@ -16,9 +16,10 @@ func CreateService(parent inject.Injector, config Config) (hierarchical.Service,
 	return svc.(*Service), err
 }
 
-func newService(config Config) *Service {
+func newService(config Config, logger *log.Logger) *Service {
 	return &Service{
 		config: config,
+		logger: logger,
 	}
 }
 
@@ -27,12 +28,13 @@ type Config struct {
 
 type Service struct {
 	config Config
+	logger *log.Logger
 }
 
-func (Service) Start() {
-	fmt.Println("Inventory service starting")
+func (s Service) Start() {
+	s.logger.Println("Inventory service starting")
 }
 
-func (Service) Stop() {
-	fmt.Println("Inventory service stopping")
+func (s Service) Stop() {
+	s.logger.Println("Inventory service stopping")
 }
@@ -2,12 +2,24 @@ package payment
 
 import (
 	"github.com/eluv-io/inject-go"
+	"github.com/eluv-io/inject-go/example/hierarchical"
 )
 
 func NewModule(conf Config) inject.Module {
 	m := inject.NewModule()
 	m.Bind(Config{}).ToSingleton(conf)
+	m.Bind(hierarchical.ServiceName("")).ToSingleton(hierarchical.ServiceName("payment"))
 	m.BindSingletonConstructor(newService)
 	m.BindSingletonConstructor(newCreditCardProcessor)
 	return m
 }
+
+// NewRegistryModule registers the payment service in the services registry of
+// the global injector.
+func NewRegistryModule() inject.Module {
+	m := inject.NewModule()
+	m.BindMap(map[string]hierarchical.ServiceFactory(nil)).ToSingleton("Payment", func(parent inject.Injector, config interface{}) (hierarchical.Service, error) {
+		return CreateService(parent, config.(Config))
+	})
+	return m
+}
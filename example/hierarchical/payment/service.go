@@ -2,6 +2,7 @@ package payment
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/eluv-io/inject-go"
 	"github.com/eluv-io/inject-go/example/hierarchical"
@@ -21,10 +22,11 @@ func CreateService(parent inject.Injector, config Config) (hierarchical.Service,
 	return svc.(*Service), err
 }
 
-func newService(config Config, processor Processor) *Service {
+func newService(config Config, processor Processor, logger *log.Logger) *Service {
 	return &Service{
 		config:    config,
 		processor: processor,
+		logger:    logger,
 	}
 }
 
@@ -34,13 +36,14 @@ type Config struct {
 type Service struct {
 	config    Config
 	processor Processor
+	logger    *log.Logger
 }
 
 func (s *Service) Start() {
-	fmt.Println("Payment service starting")
+	s.logger.Println("Payment service starting")
 	s.processor.Process(19.75)
 }
 
 func (s *Service) Stop() {
-	fmt.Println("Payment service stopping")
+	s.logger.Println("Payment service stopping")
 }
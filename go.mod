@@ -1,6 +1,6 @@
 module github.com/eluv-io/inject-go
 
-go 1.12
+go 1.18
 
 require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
@@ -107,6 +107,10 @@ passed to NewInjector().
 		return &SayHelloToSomeoneOne{sayHello, "Alice"}, nil
 	}
 
+A panic in a constructor is recovered and returned as an error that contains the panic value, the stack of the panic
+and the dependency path that lead to the constructor. The same applies to functions invoked through Call and
+CallTagged and to eager function calls.
+
 A singleton constructor will be called exactly once for the entire application.
 
 	var (
@@ -151,6 +155,34 @@ verbose ones above:
 	module.BindSingletonConstructor(newSayHello)
 	module.BindConstructor(newSayHello)
 
+By default, a constructor may return a nil value. A module can instead reject nil results, in which case a constructor
+returning a nil pointer, interface, map, channel or function (with a nil error) fails with an error naming the
+constructor and the binding key. Constructors that legitimately return nil can opt out:
+
+	module.RejectNilResults()
+	module.BindSingletonConstructor(newDB)
+	module.BindConstructor(newOptionalCache).AllowNil()
+
+To reject nil results in the entire injector and its child injectors, install the module returned by
+inject.RejectNilResults():
+
+	injector, err := inject.NewInjector(inject.RejectNilResults(), appModule, libModule)
+
+If a singleton constructor fails, its error is returned for all subsequent requests of the singleton. A retry policy
+allows recovering from transient failures, e.g. a database that is not reachable yet. Concurrent requests still
+result in a single instance:
+
+	// call the constructor again on the next request
+	module.BindSingletonConstructor(newDB).WithRetry(inject.RetryOnNextGet())
+	// call the constructor up to 5 times, waiting 100ms, 200ms, 400ms, ... in between
+	module.BindSingletonConstructor(newDB).WithRetry(inject.RetryWithBackoff(5, 100*time.Millisecond))
+
+Constructors that may hang, e.g. clients of external services, can be given a timeout. This applies to eager singletons
+at injector creation as well as to lazy requests. If the constructor does not return in time, the request fails with an
+error that contains the dependency path:
+
+	module.BindSingletonConstructor(newPaymentClient).WithTimeout(5 * time.Second).Eagerly()
+
 
 Eager Singletons
 
@@ -160,6 +192,10 @@ A singleton bound through a constructor function can be marked as _eager_, in wh
 
 The advantage is that every time the singleton is injected it is already available, whereas a normal (_lazy_) singleton has to be created before injecting it the first time. Eager singletons also reveal initialization problems sooner - at the time of injector creation rather than the first time the singleton is used.
 
+If the creation of an eager singleton fails, the injector is not created and all singletons that were created up to
+that point are stopped again (see Lifecycle below). The returned error names the failing eager singleton.
+It wraps the original error, which can be checked with errors.Is and errors.As.
+
 In addition, an eager singleton can be combined with an additional arbitrary function call, that will also be performed by the injector on construction. This can be used, for example, to initialize a "traditional" singleton implemented with a global variable:
 
 	func newAcmeLib() acme.Lib { ... }
@@ -197,6 +233,82 @@ requirements as constructors, but can have any return types.
 See the methods on Module and Constructor for more details.
 
 
+Contexts
+
+Constructors and called functions may declare a parameter of type context.Context. It receives the context passed to
+GetContext, GetTaggedContext, CallContext or CallTaggedContext, or to NewInjectorContext for eager singletons. The
+variants without context pass context.Background(). This allows slow constructors to be cancelled and to access
+request-scoped values. The creation of a value is aborted with an error once the context is done.
+
+	func newDB(ctx context.Context, config *Config) (*DB, error) {
+		return Connect(ctx, config.URL)
+	}
+
+	db, err := injector.GetContext(ctx, &DB{})
+
+Note that a singleton receives the context of the call that creates it.
+
+
+Scopes
+
+A constructor binding is either transient, i.e. the constructor is called for every request, or a singleton. A scope
+allows any other strategy for reusing instances, e.g. an instance per job, per batch or per tenant. A scope wraps the
+unscoped provider of a binding, which calls the constructor, with its own provider:
+
+	type jobScope struct { ... }
+
+	func (s *jobScope) String() string { return "job" }
+
+	func (s *jobScope) Scope(key fmt.Stringer, unscoped inject.Provider) inject.Provider {
+		return func(ctx context.Context) (interface{}, error) {
+			// return the instance of the job in ctx, or create it with unscoped(ctx)
+		}
+	}
+
+	module.Bind(&JobStats{}).ToScopedConstructor(newJobStats, scope)
+	module.BindConstructor(newJobStats).In(scope)
+
+The dependency tree and errors show the scope of each binding, e.g. "job <newJobStats...>" or "singleton <newDB...>".
+Instances of scoped bindings are not stopped by Injector.Close - that is the responsibility of the scope.
+
+The built-in ContextScope keeps its instances in a context.Context, e.g. per request. EnterScope returns a context in
+which instances of bindings in the ContextScope are created once and shared by all requests of the injector with that
+context. ExitScope stops these instances and calls the cleanup functions of their constructors, even if the context
+is already done, e.g. because the client disconnected. This is much cheaper than creating a child injector per request:
+
+	module.BindConstructor(newTransaction).In(inject.ContextScope)
+
+	func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+		ctx := inject.EnterScope(r.Context())
+		defer inject.ExitScope(ctx)
+		values, err := h.injector.CallContext(ctx, handleRequest)
+		...
+	}
+
+A PoolScope hands out instances of expensive helpers that are not safe for concurrent use (encoders, buffers, parsers)
+from a pool per binding, backed by sync.Pool. Release returns an instance to its pool, resetting it first if it
+implements Resetter. Only pointers can be pooled, and every instance should be released:
+
+	pool := inject.NewPoolScope()
+	module.BindConstructor(newEncoder).In(pool)
+	...
+	encoder, err := injector.Get(&Encoder{})
+	defer pool.Release(encoder)
+
+A TTLScope caches instances that must be rebuilt periodically, e.g. credentials or remote configuration, for a fixed
+duration. With RefreshAhead, instances are refreshed in the background shortly before they expire, and the last good
+instance keeps being served if a refresh fails. Closing the injector stops the background refreshes. The Clock of
+the scope can be replaced in tests:
+
+	module.BindConstructor(newCredentials).In(&inject.TTLScope{TTL: time.Hour, RefreshAhead: 5 * time.Minute})
+
+The WeakScope shares large instances, e.g. caches, while they are in use, but holds them only through weak pointers.
+Once nothing else references an instance, it can be garbage collected and is recreated on the next request. The scope is
+created for a type T and holds bindings of type *T only. It requires Go 1.24 or later:
+
+	module.BindConstructor(newThumbnailCache).In(inject.WeakScope[ThumbnailCache]())
+
+
 Tags
 
 A tag allows named multiple bindings of one type. As an example, let's consider if we want to
@@ -335,6 +447,96 @@ A constructor can mix tagged values with untagged values in the input struct.
 
 The CallTagged function works similarly to Call, except can take parameters like a tagged constructor.
 
+All tagged bindings of an interface or pointer type can be injected at once as a map by tag, including those declared
+in parent injectors. New tagged bindings then show up without changing the consumer. An explicit binding of the map type
+takes precedence. Maps of tagged constants, e.g. map[string]string, are not injected this way.
+
+	func newGreeter(sayHellos map[string]SayHello) *Greeter {
+		return &Greeter{sayHellos} // english, german and austrian
+	}
+
+Injector.GetAllTagged returns the same map:
+
+	sayHellos, err := injector.GetAllTagged((*SayHello)(nil))
+
+
+Optional Dependencies
+
+By default, a missing binding for any parameter of a constructor or field of a populated struct fails validation. A
+parameter or field of type inject.Optional[T] is injected with the value bound to T, or with an empty Optional if
+nothing is bound:
+
+	func newServer(tracer inject.Optional[Tracer]) *Server {
+		return &Server{tracer: tracer.OrElse(noopTracer{})}
+	}
+
+Struct fields can also be marked as optional in their tag, in which case they keep their zero value if nothing is
+bound. Fields of constant types such as int or string may instead declare a default value:
+
+	func newServer(s struct {
+		Tracer Tracer `inject:",optional"`
+		Name   string `inject:"name,optional"`
+		Port   int    `inject:"port,default=8080"`
+	}) *Server {
+		...
+	}
+
+
+Multibindings
+
+Extension points like HTTP routes, health checks or migrations often collect one implementation from each of many
+modules. A regular binding can only be declared once, but any module can add elements to a multibinding of a slice type.
+Injecting the slice type then provides all elements:
+
+	// in the users module
+	module.BindMulti([]Route(nil)).ToSingleton(&UsersRoute{})
+
+	// in the metrics module
+	module.BindMulti([]Route(nil)).ToConstructor(newMetricsRoute)
+	module.BindMulti([]Route(nil)).ToTaggedConstructor(newAdminRoute).WithPriority(10)
+
+	func newServer(routes []Route) *Server { ... }
+
+Elements may be singletons, constructors, singleton constructors or tagged constructors. They are ordered by descending
+priority and then in binding order. BindTaggedMulti declares a tagged multibinding.
+
+Adding the same element twice, e.g. by installing a module twice, fails the creation of the injector. If any module
+calls PermitDuplicates on the multibinding, duplicates are dropped instead. The elements are collected from the modules
+of a single injector: a child injector cannot add elements to a multibinding of its parent, this fails with an "already
+bound" error like any other binding of the parent.
+
+Similarly, modules can contribute keyed entries to a map binding of any map type. Injecting the map type then provides
+all entries, e.g. as a registry of factories:
+
+	// in the payment module
+	module.BindMap(map[string]ServiceFactory(nil)).ToSingleton("Payment", newPaymentService)
+
+	// in the inventory module
+	module.BindMap(map[string]ServiceFactory(nil)).ToConstructor("Inventory", newInventoryServiceFactory)
+
+	func newServicesFactory(registry map[string]ServiceFactory) *ServicesFactory { ... }
+
+Adding two entries with the same key fails the creation of the injector with an error showing the code locations that
+added them.
+
+
+Default Bindings
+
+A library module can provide a default binding that applications replace with a regular binding of the same key, without
+resorting to Override(...).With(...), which is meant for tests. A default binding yields to a regular binding in any
+other module of the same injector:
+
+	// in the library module
+	module.BindDefault((*Tracer)(nil)).ToSingleton(noopTracer{})
+
+	// in the application module, replaces the default tracer
+	module.BindSingletonConstructor(newJaegerTracer)
+
+The dependency tree shows which binding won, e.g. "{type:*lib.Tracer} : singleton <app.newJaegerTracer() lib.Tracer>
+(replaces default singleton lib.noopTracer)". Two default bindings of the same key are still an error. A child
+injector cannot replace a default binding of its parent, since the singletons of the parent may already depend on it;
+binding the key in a child injector fails like for any other binding of the parent.
+
 
 Child Injectors
 
@@ -349,12 +551,89 @@ that is not available at creation time of the (parent) injector.
 See this discussion on hierarchical injectors for further information and possible alternatives using factories:
 https://publicobject.com/2008/06/whats-hierarchical-injector.html
 
+A child injector that is no longer needed should be disposed with Close. This stops the singletons local to the child
+injector (see Lifecycle below) and releases the child from its parent, without affecting the singletons of the parent.
+Closing the parent also closes the children that have singletons to stop. The parent does not keep any other children,
+so a short-lived child without such singletons may simply be dropped.
+
+Singletons bound in the parent injector are shared by all of its children. A singleton that every child needs its own
+instance of, e.g. a logger or metrics registry per service, can be declared once in the parent with PerChildInjector
+instead of being redeclared in the modules of each child. Each child then creates its own instance lazily, resolving the
+dependencies in the child injector:
+
+	module.BindSingletonConstructor(func(name ServiceName) *Logger { ... }).PerChildInjector()
+
+Creating a child injector installs and validates its modules every time. When many children are created for the same
+modules, e.g. one per tenant or per job, a ChildInjectorTemplate does this once and then creates the children cheaply,
+each with its own child-local singletons:
+
+	template, err := injector.NewChildInjectorTemplate(nil, tenantModules...)
+	...
+	tenantInjector, err := template.NewNamedChildInjector(tenantID)
+
+A ChildInjectorCache keeps child injectors by key, e.g. per tenant. It creates them on demand with the modules for the
+key, shares one creation among concurrent requests for the same key, and closes evicted children once the cache exceeds
+its maximum size or children have been idle for too long:
+
+	cache := &inject.ChildInjectorCache{Parent: injector, Modules: tenantModules, MaxSize: 1000, IdleTimeout: time.Hour}
+	defer cache.Close(ctx)
+	...
+	tenantInjector, err := cache.Get(ctx, tenantID)
+
+An evicted child is closed even if it is still in use, so call Get for every request instead of holding on to a child.
+
+
+Lifecycle
+
+Singletons created by singleton constructors often own resources like connection pools, file handles or background
+goroutines. If such a singleton implements the Stopper interface or io.Closer, the injector keeps track of it once it
+is instantiated, and stops it when the injector is closed:
+
+	type DB struct { ... }
+
+	func (db *DB) Close() error { ... }
+
+	module.BindSingletonConstructor(newDB)
+	injector, err := inject.NewInjector(module)
+	...
+	defer injector.Close(context.Background())
+
+Singletons are stopped in the reverse order of their creation, so a singleton is always stopped before its
+dependencies.
+
+Alternatively, a constructor may return a cleanup function in addition to the value (and optional error). The cleanup
+function is called when the injector is closed, in reverse construction order like the singletons above:
+
+	func newDB(cfg Config) (*DB, func(), error) {
+		db, err := openDB(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return db, func() { db.shutdown() }, nil
+	}
+
+Only singleton constructors and constructors in the ContextScope may return a cleanup function. The cleanup functions
+of the latter are called when the scope is exited. Other bindings fail the creation of the injector, since the cleanup
+functions of all their instances would pile up until the injector is closed.
+
+Run builds an injector and drives the lifecycle of an entire application: it starts all instantiated singletons that
+implement the Starter interface in dependency order, blocks until the context is cancelled or SIGINT/SIGTERM is
+received, and then closes the injector. Use a Runner to configure the shutdown timeout or the signals.
+
+	module.BindSingletonConstructor(newServer).Eagerly()
+	err := inject.Run(context.Background(), module)
+
 
 Diagnostics
 
 Both Module and Injector implement fmt.Stringer for inspection, however this may be added to in the future
 to allow semantic inspection of bindings.
 
+Circular dependencies between bindings are detected when the injector is created. A constructor may also request
+values at runtime through an injected Injector. Requests made through it while the constructor is running continue the
+resolution path of the constructor, even from other goroutines. If such a request leads back to a value that is being
+constructed on that path, it fails with a circular dependency error showing the runtime path instead of deadlocking.
+
 
 Unit Testing
 
@@ -371,7 +650,9 @@ For testing, production modules may be overridden with test bindings as follows:
 package inject // import "github.com/eluv-io/inject-go"
 
 import (
+	"context"
 	"fmt"
+	"time"
 )
 
 // Module sets up your dependencies.
@@ -380,7 +661,7 @@ import (
 // to make sure multiple goroutines are not calling a single module.
 type Module interface {
 	fmt.Stringer
-	BindConstructor(fn interface{})
+	BindConstructor(fn interface{}) ConstructorBuilder
 	BindSingletonConstructor(fn interface{}) SingletonBuilder
 	BindSingleton(singleton interface{})
 	Bind(from ...interface{}) Builder
@@ -409,6 +690,72 @@ type Module interface {
 	// This works like BindSingletonConstructor(...).EagerlyAndCall(fn) but without binding a constructor.
 	// Useful to instantiate standalone "services" that are not injected into other components.
 	CallEagerly(function interface{})
+	// RejectNilResults makes constructors of this module fail with an error
+	// instead of returning a nil pointer, interface, map, channel or function
+	// with a nil error. This applies to all constructor bindings of the module,
+	// including those installed from other modules. Use AllowNil on the
+	// builder of a binding to exempt it.
+	//
+	// To reject nil results in an entire injector, install the module
+	// returned by the function RejectNilResults:
+	//   injector, err := inject.NewInjector(inject.RejectNilResults(), appModule, libModule)
+	RejectNilResults()
+
+	// BindMulti returns a builder for adding an element to the multibinding
+	// of the given slice type, e.g. []Route(nil). Any number of modules may
+	// add elements to the same multibinding, and injecting the slice type
+	// provides all of them. Elements are ordered by descending priority (see
+	// ElementBuilder.WithPriority), then in the order of their binding: in the
+	// order of the modules passed to the injector or installed into another
+	// module, and within a module in the order of the calls to the builder.
+	//
+	// Adding the same element twice, i.e. installing the same module twice or
+	// adding equal singleton pointers or basic values, fails the creation of
+	// the injector unless duplicates are permitted by any of the modules. See
+	// MultiBuilder.PermitDuplicates.
+	//
+	// A multibinding cannot be combined with a regular binding of the same
+	// slice type, and an override module (see Override) replaces all elements
+	// of a multibinding. Calling BindMulti without adding any elements binds
+	// the slice type to an empty slice.
+	//
+	// The elements are collected from the modules of a single injector. A
+	// child injector cannot add elements to a multibinding of its parent:
+	// binding the slice type in the child fails with an "already bound"
+	// error.
+	BindMulti(sliceType interface{}) MultiBuilder
+	// BindTaggedMulti works like BindMulti for the slice type with the given
+	// tag.
+	BindTaggedMulti(tag string, sliceType interface{}) MultiBuilder
+
+	// BindMap returns a builder for adding an entry to the map binding of the
+	// given map type, e.g. map[string]Factory(nil). Any number of modules may
+	// add entries to the same map binding, and injecting the map type provides
+	// all of them. Adding two entries with the same key fails the creation of
+	// the injector with an error showing the code locations that added them.
+	//
+	// As with BindMulti, a map binding cannot be combined with a regular
+	// binding of the same map type, an override module replaces all entries,
+	// calling BindMap without adding any entries binds the map type to an
+	// empty map, and a child injector cannot add entries to a map binding of
+	// its parent.
+	BindMap(mapType interface{}) MapBuilder
+	// BindTaggedMap works like BindMap for the map type with the given tag.
+	BindTaggedMap(tag string, mapType interface{}) MapBuilder
+
+	// BindDefault works like Bind, but declares default bindings, e.g. for the
+	// default implementation of an interface provided by a library module. A
+	// default binding yields to a regular binding of the same key, declared
+	// in any other module of the same injector, instead of failing the
+	// creation of the injector. Unlike Override, this is meant for production
+	// code. Two default bindings of the same key are an error, and so is
+	// binding the key of a default binding of the parent in a child injector.
+	//
+	// The dependency tree shows whether the default binding is used or which
+	// binding replaces it.
+	BindDefault(from ...interface{}) Builder
+	// BindTaggedDefault works like BindDefault for the given tag.
+	BindTaggedDefault(tag string, from ...interface{}) Builder
 }
 
 // NewModule creates a new Module.
@@ -417,9 +764,12 @@ func NewModule() Module { return newModule() }
 // Builder is the return value from a Bind call from a Module.
 type Builder interface {
 	ToSingleton(singleton interface{})
-	ToConstructor(constructor interface{})
+	ToConstructor(constructor interface{}) ConstructorBuilder
+	// ToScopedConstructor binds to the given constructor in the given scope.
+	// It is a shortcut for ToConstructor(constructor).In(scope).
+	ToScopedConstructor(constructor interface{}, scope Scope) ConstructorBuilder
 	ToSingletonConstructor(constructor interface{}) SingletonBuilder
-	ToTaggedConstructor(constructor interface{})
+	ToTaggedConstructor(constructor interface{}) ConstructorBuilder
 	ToTaggedSingletonConstructor(constructor interface{}) SingletonBuilder
 }
 
@@ -429,6 +779,69 @@ type InterfaceBuilder interface {
 	To(to interface{})
 }
 
+// MultiBuilder adds elements to a multibinding, see Module.BindMulti.
+type MultiBuilder interface {
+	// ToSingleton adds the given value as element.
+	ToSingleton(element interface{}) ElementBuilder
+	// ToConstructor adds an element that is created by the given constructor
+	// whenever the slice is injected.
+	ToConstructor(constructor interface{}) ElementBuilder
+	// ToSingletonConstructor adds an element that is created once by the
+	// given constructor.
+	ToSingletonConstructor(constructor interface{}) ElementBuilder
+	// ToTaggedConstructor adds an element that is created by the given
+	// tagged constructor whenever the slice is injected.
+	ToTaggedConstructor(constructor interface{}) ElementBuilder
+	// PermitDuplicates drops elements that have been added before instead of
+	// failing the creation of the injector.
+	PermitDuplicates() MultiBuilder
+}
+
+// MapBuilder adds entries to a map binding, see Module.BindMap.
+type MapBuilder interface {
+	// ToSingleton adds an entry with the given key and value.
+	ToSingleton(key interface{}, value interface{})
+	// ToConstructor adds an entry with the given key and a value that is
+	// created by the given constructor whenever the map is injected.
+	ToConstructor(key interface{}, constructor interface{})
+	// ToSingletonConstructor adds an entry with the given key and a value that
+	// is created once by the given constructor.
+	ToSingletonConstructor(key interface{}, constructor interface{})
+	// ToTaggedConstructor adds an entry with the given key and a value that
+	// is created by the given tagged constructor whenever the map is injected.
+	ToTaggedConstructor(key interface{}, constructor interface{})
+}
+
+// ElementBuilder is returned when adding an element to a multibinding.
+type ElementBuilder interface {
+	// WithPriority sets the priority of the element. Elements with a higher
+	// priority come first in the slice. The default priority is 0.
+	WithPriority(priority int) ElementBuilder
+}
+
+// ConstructorBuilder is returned when binding a constructor.
+type ConstructorBuilder interface {
+	// AllowNil allows the constructor to return nil values even if its module
+	// rejects nil results. See Module.RejectNilResults.
+	AllowNil() ConstructorBuilder
+
+	// In provides the values of the constructor through the given scope,
+	// which determines when the constructor is called and for how long its
+	// values are reused. See Scope.
+	In(scope Scope) ConstructorBuilder
+
+	// WithTimeout limits the time for creating a value with the constructor,
+	// including the creation of its dependencies. If the constructor does not
+	// return in time, the request fails with a timeout error that contains
+	// the dependency path. The context.Context passed to the constructor is
+	// cancelled at the same time.
+	//
+	// Note that a constructor that ignores its context keeps running in the
+	// background. Its result is discarded when it returns: a value
+	// implementing Stopper or io.Closer is stopped right away.
+	WithTimeout(timeout time.Duration) ConstructorBuilder
+}
+
 // SingletonBuilder is returned when binding a singleton constructor.
 type SingletonBuilder interface {
 	// Eagerly creates the singleton (by calling its constructor) right after
@@ -441,6 +854,45 @@ type SingletonBuilder interface {
 	// created singleton instance. This can be useful when integrating
 	// 3rd-party libraries that rely on such singletons. Use with caution!
 	EagerlyAndCall(function interface{})
+
+	// AllowNil allows the constructor to return a nil singleton even if its
+	// module rejects nil results. See Module.RejectNilResults.
+	AllowNil() SingletonBuilder
+
+	// WithRetry sets the retry policy for the case that the constructor of the
+	// singleton fails, e.g. because a database is not reachable yet. By
+	// default, the constructor is called only once and its error is returned
+	// for all subsequent requests of the singleton. See RetryOnNextGet and
+	// RetryWithBackoff.
+	//
+	// In any case, concurrent requests of the singleton wait for a single
+	// call of the constructor and at most one instance is created.
+	WithRetry(retry RetryPolicy) SingletonBuilder
+
+	// WithTimeout limits the time for creating the singleton, see
+	// ConstructorBuilder.WithTimeout. This applies to eager singletons created
+	// during the creation of the injector as well. Each attempt of a retry
+	// policy has its own timeout.
+	//
+	// A constructor that is still running after its timeout is never called a
+	// second time in parallel: further attempts and requests wait for it
+	// instead, again within the timeout. If it returns a value before the
+	// singleton is created otherwise, and the error of the timed out attempt
+	// is not cached (see RetryOnNextGet), the value becomes the singleton.
+	// Otherwise it is discarded as described for ConstructorBuilder.WithTimeout.
+	WithTimeout(timeout time.Duration) SingletonBuilder
+
+	// PerChildInjector creates a separate instance of the singleton in each
+	// child injector of the injector it is bound in, instead of a single
+	// instance shared by all children. Each child creates its instance lazily
+	// and resolves its dependencies itself, so the singleton may depend on
+	// bindings that are only declared in the child modules. These dependencies
+	// are validated when the child injector is created.
+	//
+	// The singleton is not available in the injector it is bound in, only in
+	// its children. Children of a child injector share the instance of the
+	// child. Eager per-child singletons are created with each child injector.
+	PerChildInjector() SingletonBuilder
 }
 
 // Injector provides your dependencies.
@@ -468,6 +920,29 @@ type Injector interface {
 	CallTagged(taggedFunction interface{}) ([]interface{}, error)
 	Populate(populateStruct interface{}) error
 
+	// GetContext works like Get, but passes the given context to all
+	// constructors with a parameter of type context.Context. The creation of
+	// the value is aborted with an error once the context is done.
+	//
+	// Note that a singleton receives the context of the call that creates it.
+	GetContext(ctx context.Context, from interface{}) (interface{}, error)
+	// GetTaggedContext works like GetTagged with a context as in GetContext.
+	GetTaggedContext(ctx context.Context, tag string, from interface{}) (interface{}, error)
+	// CallContext works like Call with a context as in GetContext. The called
+	// function may also declare a parameter of type context.Context.
+	CallContext(ctx context.Context, function interface{}) ([]interface{}, error)
+	// CallTaggedContext works like CallTagged with a context as in CallContext.
+	CallTaggedContext(ctx context.Context, taggedFunction interface{}) ([]interface{}, error)
+
+	// GetAllTagged returns the values of all tagged bindings of the given
+	// type by tag, including those of the ancestors of this injector. For
+	// interface and pointer types, this is the same map that is injected for
+	// a parameter of type map[string]T.
+	GetAllTagged(from interface{}) (map[string]interface{}, error)
+	// GetAllTaggedContext works like GetAllTagged with a context as in
+	// GetContext.
+	GetAllTaggedContext(ctx context.Context, from interface{}) (map[string]interface{}, error)
+
 	// DependencyTree returns the full dependency tree of this injector.
 	DependencyTree() (DependencyTree, error)
 
@@ -514,6 +989,36 @@ type Injector interface {
 	// NewChildInjector calls NewNamedChildInjector with the caller's code
 	// location as name.
 	NewChildInjector(overridesType interface{}, modules ...Module) (Injector, error)
+
+	// NewChildInjectorTemplate installs and validates the given child modules
+	// once and returns a template for creating any number of child injectors
+	// for these modules, as with NewNamedChildInjector. This is considerably
+	// cheaper than calling NewNamedChildInjector for every child, e.g. when
+	// creating a child injector per tenant or per job. The modules must not be
+	// changed after the creation of the template, and the override module is
+	// looked up once as well.
+	NewChildInjectorTemplate(overridesType interface{}, modules ...Module) (ChildInjectorTemplate, error)
+
+	// Close stops all singletons that were created by singleton constructors
+	// of this injector and implement Stopper or io.Closer, and calls the
+	// cleanup functions returned by constructors of this injector. Singletons
+	// and cleanup functions are processed in reverse creation order, i.e. a
+	// singleton is stopped before any of its dependencies. Singletons that
+	// were never instantiated are not touched.
+	//
+	// All singletons are stopped even if some of them fail, and the errors
+	// are returned as one error. If the given context is done before all
+	// singletons are stopped, the remaining ones are skipped, and calling
+	// Close again stops them.
+	//
+	// Child injectors that have singletons or cleanup functions to stop and
+	// have not been closed yet are closed first. Closing a child injector only
+	// stops its own singletons, never the ones owned by its parent, and
+	// removes the child from its parent.
+	//
+	// Once closed, the injector and all its descendants refuse to provide any
+	// further values, and return an "injector closed" error instead.
+	Close(ctx context.Context) error
 }
 
 // NewInjector calls NewNamedInjector with the caller's code location as name.
@@ -526,5 +1031,13 @@ func NewInjector(modules ...Module) (Injector, error) {
 // Note that Modules are not thread-safe, it is your responsibility to make sure
 // all Modules have all bindings in place before passing them as parameters to NewInjector.
 func NewNamedInjector(name string, modules ...Module) (Injector, error) {
-	return newInjector(name, modules...)
+	return newInjector(context.Background(), name, modules...)
+}
+
+// NewInjectorContext creates a new Injector like NewInjector, but passes the
+// given context to the constructors of eager singletons and to eager function
+// calls. Creation fails if the context is done before all eager singletons are
+// created.
+func NewInjectorContext(ctx context.Context, modules ...Module) (Injector, error) {
+	return newInjector(ctx, callerName(3, "root"), modules...)
 }
@@ -3,6 +3,7 @@ package inject
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -33,3 +34,109 @@ func TestCircularDependencies(t *testing.T) {
 
 	fmt.Println(err)
 }
+
+type reentrantA struct{}
+
+type reentrantB struct{}
+
+type reentrantC struct{}
+
+type reentrantFactory struct {
+	inj Injector
+}
+
+func TestRuntimeCircularDependencies(t *testing.T) {
+	mod := NewModule()
+	// a calls itself through the injector
+	mod.BindSingletonConstructor(func(inj Injector) (*reentrantA, error) {
+		_, err := inj.Get(&reentrantA{})
+		return &reentrantA{}, err
+	})
+	// b calls c through the injector, which calls b - with a timeout, so
+	// that c runs on a separate goroutine
+	mod.BindSingletonConstructor(func(inj Injector) (*reentrantB, error) {
+		_, err := inj.Get(&reentrantC{})
+		return &reentrantB{}, err
+	}).WithTimeout(5 * time.Second)
+	mod.BindConstructor(func(inj Injector) (*reentrantC, error) {
+		_, err := inj.Call(func(b *reentrantB) {})
+		return &reentrantC{}, err
+	})
+	inj, err := NewInjector(mod)
+	require.NoError(t, err)
+
+	_, err = inj.Get(&reentrantA{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeCircularDependency)
+	require.Contains(t, err.Error(), "* {type:*inject.reentrantA}")
+
+	_, err = inj.Get(&reentrantB{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeCircularDependency)
+	require.Contains(t, err.Error(), "* {type:*inject.reentrantB}")
+	require.Contains(t, err.Error(), "* {type:*inject.reentrantC}")
+	require.NotContains(t, err.Error(), injectErrorTypeTimeout)
+
+	fmt.Println(err)
+}
+
+func TestRuntimeCircularDependenciesOtherGoroutine(t *testing.T) {
+	mod := NewModule()
+	mod.BindSingletonConstructor(func(inj Injector) (*reentrantA, error) {
+		res := make(chan error)
+		go func() {
+			_, err := inj.Get(&reentrantA{})
+			res <- err
+		}()
+		return &reentrantA{}, <-res
+	})
+	inj, err := NewInjector(mod)
+	require.NoError(t, err)
+
+	_, err = inj.Get(&reentrantA{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeCircularDependency)
+	require.Contains(t, err.Error(), "* {type:*inject.reentrantA}")
+}
+
+func TestRuntimeGetAfterConstruction(t *testing.T) {
+	mod := NewModule()
+	mod.BindSingletonConstructor(func(inj Injector) *reentrantFactory {
+		return &reentrantFactory{inj}
+	})
+	mod.BindSingletonConstructor(func(f *reentrantFactory) *reentrantA {
+		return &reentrantA{}
+	})
+	inj, err := NewInjector(mod)
+	require.NoError(t, err)
+
+	a, err := inj.Get(&reentrantA{})
+	require.NoError(t, err)
+
+	// the injector stored by the factory no longer continues the path of its
+	// construction
+	f, err := inj.Get(&reentrantFactory{})
+	require.NoError(t, err)
+	a2, err := f.(*reentrantFactory).inj.Get(&reentrantA{})
+	require.NoError(t, err)
+	require.True(t, a == a2)
+	_, err = f.(*reentrantFactory).inj.Get(&reentrantFactory{})
+	require.NoError(t, err)
+}
+
+func TestRuntimeNestedGet(t *testing.T) {
+	mod := NewModule()
+	mod.BindSingletonConstructor(func(inj Injector) (*reentrantA, error) {
+		_, err := inj.Get(&reentrantB{})
+		return &reentrantA{}, err
+	})
+	mod.BindSingletonConstructor(func(c *reentrantC) *reentrantB { return &reentrantB{} })
+	mod.BindConstructor(func() *reentrantC { return &reentrantC{} })
+	inj, err := NewInjector(mod)
+	require.NoError(t, err)
+
+	_, err = inj.Get(&reentrantA{})
+	require.NoError(t, err)
+	_, err = inj.Get(&reentrantB{})
+	require.NoError(t, err)
+}
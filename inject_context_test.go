@@ -0,0 +1,150 @@
+package inject
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKey struct{}
+
+type ctxRequest struct {
+	id string
+}
+
+type ctxDB struct{}
+
+func newCtxRequest(ctx context.Context) *ctxRequest {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return &ctxRequest{id}
+}
+
+func TestGetContext(t *testing.T) {
+	module := NewModule()
+	module.BindConstructor(newCtxRequest)
+	module.BindTagged("tagged", &ctxRequest{}).ToTaggedConstructor(func(s struct {
+		Ctx context.Context
+	}) *ctxRequest {
+		return newCtxRequest(s.Ctx)
+	})
+	for _, injector := range createInjectors(t, module) {
+		ctx := context.WithValue(context.Background(), ctxKey{}, "42")
+
+		request, err := injector.GetContext(ctx, &ctxRequest{})
+		require.NoError(t, err)
+		require.Equal(t, "42", request.(*ctxRequest).id)
+
+		request, err = injector.GetTaggedContext(ctx, "tagged", &ctxRequest{})
+		require.NoError(t, err)
+		require.Equal(t, "42", request.(*ctxRequest).id)
+
+		values, err := injector.CallContext(ctx, func(ctx context.Context, r *ctxRequest) string {
+			return ctx.Value(ctxKey{}).(string) + r.id
+		})
+		require.NoError(t, err)
+		require.Equal(t, "4242", values[0])
+
+		request, err = injector.Get(&ctxRequest{})
+		require.NoError(t, err)
+		require.Equal(t, "", request.(*ctxRequest).id)
+	}
+}
+
+func TestGetContextDone(t *testing.T) {
+	var calls int
+	module := NewModule()
+	module.BindSingletonConstructor(func(ctx context.Context) (*ctxDB, error) {
+		calls++
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return &ctxDB{}, nil
+	})
+	module.BindConstructor(func(db *ctxDB) *ctxRequest { return &ctxRequest{} })
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = injector.GetContext(ctx, &ctxRequest{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeContextDone)
+	require.True(t, errors.Is(err, context.Canceled))
+	require.Equal(t, 0, calls)
+
+	_, err = injector.Get(&ctxRequest{})
+	require.NoError(t, err)
+	require.Equal(t, 1, calls)
+}
+
+func TestGetContextDoneWhileWaitingForSingleton(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	module := NewModule()
+	module.BindSingletonConstructor(func() *ctxDB {
+		close(started)
+		<-release
+		return &ctxDB{}
+	})
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		_, err := injector.Get(&ctxDB{})
+		done <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = injector.GetContext(ctx, &ctxDB{})
+	require.Error(t, err)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	close(release)
+	require.NoError(t, <-done)
+}
+
+func TestSingletonErrorNotCachedIfContextDone(t *testing.T) {
+	module := NewModule()
+	module.BindSingletonConstructor(func(ctx context.Context) (*ctxDB, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Millisecond):
+			return &ctxDB{}, nil
+		}
+	})
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go cancel()
+	_, _ = injector.GetContext(ctx, &ctxDB{})
+
+	db, err := injector.Get(&ctxDB{})
+	require.NoError(t, err)
+	require.NotNil(t, db)
+}
+
+func TestNewInjectorContext(t *testing.T) {
+	var id string
+	module := NewModule()
+	module.BindSingletonConstructor(newCtxRequest).EagerlyAndCall(func(r *ctxRequest) {
+		id = r.id
+	})
+
+	_, err := NewInjectorContext(context.WithValue(context.Background(), ctxKey{}, "eager"), module)
+	require.NoError(t, err)
+	require.Equal(t, "eager", id)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewInjectorContext(ctx, module)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, context.Canceled))
+}
@@ -11,7 +11,7 @@ const (
 	injectErrorTypeReflectTypeNil                 = "reflect.TypeOf() returns nil"
 	injectErrorTypeNotSupportedYet                = "Binding type not supported yet, feel free to help!"
 	injectErrorTypeNotAssignable                  = "Binding not assignable"
-	injectErrorTypeConstructorReturnValuesInvalid = "Constructor can only have two return values, the first providing the value, the second being an error"
+	injectErrorTypeConstructorReturnValuesInvalid = "Constructor must return the value, optionally followed by a cleanup function func() and/or an error"
 	injectErrorTypeIntermediateBinding            = "Trying to get for an intermediate binding"
 	injectErrorTypeFinalBinding                   = "Trying to get bindingKey for a final binding"
 	injectErrorTypeCannotCastModule               = "Cannot cast Module to internal module type"
@@ -28,6 +28,27 @@ const (
 	injectErrorTypeWrapped                        = "Wrapped standard error"
 	injectErrorTypeConstructorCall                = "Constructor call failed"
 	injectErrorTypeCircularDependency             = "Circular dependency"
+	injectErrorTypeCloseFailed                    = "Closing injector failed"
+	injectErrorTypeCloseAborted                   = "Closing injector aborted"
+	injectErrorTypeStopFailed                     = "Stopping singleton failed"
+	injectErrorTypeStartFailed                    = "Starting singleton failed"
+	injectErrorTypeEagerFailed                    = "Creating eager singleton failed"
+	injectErrorTypeInjectorClosed                 = "Injector closed"
+	injectErrorTypePanic                          = "Function panicked"
+	injectErrorTypeNilResult                      = "Constructor returned nil"
+	injectErrorTypeContextDone                    = "Context done"
+	injectErrorTypeTimeout                        = "Construction timed out"
+	injectErrorTypeNotInScope                     = "Not in scope"
+	injectErrorTypeScopeExited                    = "Scope exited"
+	injectErrorTypeCleanupNotOwned                = "Only singleton constructors and constructors in the ContextScope may return a cleanup function"
+	injectErrorTypeNotPooled                      = "Value was not created in this pool scope"
+	injectErrorTypeNotPoolable                    = "Values in a pool scope must be non-nil pointers"
+	injectErrorTypeWeakNotPointer                 = "Bindings in the weak scope must provide non-nil pointers of the scope's type"
+	injectErrorTypePerChildInjector               = "Singleton is only available in child injectors"
+	injectErrorTypeCacheClosed                    = "Child injector cache closed"
+	injectErrorTypeDuplicateElement               = "Duplicate element of multibinding"
+	injectErrorTypeDuplicateMapKey                = "Duplicate key of map binding"
+	injectErrorTypeInvalidInjectTag               = "Invalid inject struct tag"
 )
 
 var (
@@ -52,6 +73,27 @@ var (
 	errBindingWrapped                 = newInjectError(injectErrorTypeWrapped)
 	errConstructorCall                = newInjectError(injectErrorTypeConstructorCall)
 	errCircularDependency             = newInjectError(injectErrorTypeCircularDependency)
+	errCloseFailed                    = newInjectError(injectErrorTypeCloseFailed)
+	errCloseAborted                   = newInjectError(injectErrorTypeCloseAborted)
+	errStopFailed                     = newInjectError(injectErrorTypeStopFailed)
+	errStartFailed                    = newInjectError(injectErrorTypeStartFailed)
+	errEagerFailed                    = newInjectError(injectErrorTypeEagerFailed)
+	errInjectorClosed                 = newInjectError(injectErrorTypeInjectorClosed)
+	errPanic                          = newInjectError(injectErrorTypePanic)
+	errNilResult                      = newInjectError(injectErrorTypeNilResult)
+	errContextDone                    = newInjectError(injectErrorTypeContextDone)
+	errTimeout                        = newInjectError(injectErrorTypeTimeout)
+	errNotInScope                     = newInjectError(injectErrorTypeNotInScope)
+	errScopeExited                    = newInjectError(injectErrorTypeScopeExited)
+	errCleanupNotOwned                = newInjectError(injectErrorTypeCleanupNotOwned)
+	errNotPooled                      = newInjectError(injectErrorTypeNotPooled)
+	errNotPoolable                    = newInjectError(injectErrorTypeNotPoolable)
+	errWeakNotPointer                 = newInjectError(injectErrorTypeWeakNotPointer)
+	errPerChildInjector               = newInjectError(injectErrorTypePerChildInjector)
+	errCacheClosed                    = newInjectError(injectErrorTypeCacheClosed)
+	errDuplicateElement               = newInjectError(injectErrorTypeDuplicateElement)
+	errDuplicateMapKey                = newInjectError(injectErrorTypeDuplicateMapKey)
+	errInvalidInjectTag               = newInjectError(injectErrorTypeInvalidInjectTag)
 )
 
 type injectError struct {
@@ -79,6 +121,17 @@ func (i *injectError) withTag(key string, value interface{}, nostack ...bool) *i
 	return &injectError{i.errorType, append(i.tags, newInjectErrorTag(key, value, stack))}
 }
 
+// Unwrap returns the standard error wrapped by this error, if any, in order to
+// support errors.Is and errors.As.
+func (i *injectError) Unwrap() error {
+	for _, tag := range i.tags {
+		if err, ok := tag.value.(error); ok && tag.key == "err" {
+			return err
+		}
+	}
+	return nil
+}
+
 type injectErrorTag struct {
 	key     string
 	value   interface{}
@@ -55,12 +55,12 @@ func ExampleInjector_DependencyTree() {
 	// dependency tree:
 	// root : my injector
 	// ├── {type:*inject.Injector} : my injector
-	// ├── {type:inject_test.A} : <github.com/eluv-io/inject-go_test.newA(inject_test.B, inject_test.E) inject_test.A>
-	// │   ├── {type:inject_test.B} : <github.com/eluv-io/inject-go_test.newB(inject_test.C, inject_test.D) inject_test.B>
+	// ├── {type:inject_test.A} : singleton <github.com/eluv-io/inject-go_test.newA(inject_test.B, inject_test.E) inject_test.A>
+	// │   ├── {type:inject_test.B} : singleton <github.com/eluv-io/inject-go_test.newB(inject_test.C, inject_test.D) inject_test.B>
 	// │   │   ├── {type:inject_test.C} : singleton inject_test.C
 	// │   │   └── {type:inject_test.D} : singleton inject_test.D
 	// │   └── {type:inject_test.E} : singleton inject_test.E
-	// ├── {type:inject_test.B} : <github.com/eluv-io/inject-go_test.newB(inject_test.C, inject_test.D) inject_test.B>
+	// ├── {type:inject_test.B} : singleton <github.com/eluv-io/inject-go_test.newB(inject_test.C, inject_test.D) inject_test.B>
 	// │   ├── {type:inject_test.C} : singleton inject_test.C
 	// │   └── {type:inject_test.D} : singleton inject_test.D
 	// ├── {type:inject_test.C} : singleton inject_test.C
@@ -0,0 +1,65 @@
+package inject
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type panicking struct{}
+
+type dependsOnPanicking struct {
+	p *panicking
+}
+
+func newPanicking() *panicking {
+	panic("boom")
+}
+
+func newDependsOnPanicking(p *panicking) *dependsOnPanicking {
+	return &dependsOnPanicking{p}
+}
+
+func TestConstructorPanic(t *testing.T) {
+	module := NewModule()
+	module.BindSingletonConstructor(newPanicking)
+	module.BindConstructor(newDependsOnPanicking)
+	for _, injector := range createInjectors(t, module) {
+		t.Run(injector.name, func(t *testing.T) {
+			_, err := injector.Get(&dependsOnPanicking{})
+			require.Error(t, err)
+			msg := err.Error()
+			require.Contains(t, msg, injectErrorTypePanic)
+			require.Contains(t, msg, "panic:boom")
+			require.Contains(t, msg, "bindingKey:{type:*inject.panicking}")
+			require.Contains(t, msg, "* {type:*inject.dependsOnPanicking}")
+			require.Contains(t, msg, "function:<github.com/eluv-io/inject-go.newPanicking()")
+		})
+	}
+}
+
+func TestEagerConstructorPanic(t *testing.T) {
+	module := NewModule()
+	module.BindSingletonConstructor(newPanicking).Eagerly()
+	_, err := NewInjector(module)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypePanic)
+	require.Contains(t, err.Error(), "eager:{type:*inject.panicking}")
+}
+
+func TestCallPanic(t *testing.T) {
+	injector, err := NewInjector(NewModule())
+	require.NoError(t, err)
+
+	_, err = injector.Call(func(i Injector) {
+		panic("call boom")
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "panic:call boom")
+
+	_, err = injector.CallTagged(func(s struct{ I Injector }) {
+		panic("tagged call boom")
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "panic:tagged call boom")
+}
@@ -1,6 +1,7 @@
 package inject
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync/atomic"
@@ -1553,7 +1554,7 @@ func createInjectors(t *testing.T, module Module) []*injector {
 
 	// regular injector
 	{
-		inj, err := newInjector("regular", module)
+		inj, err := newInjector(context.Background(), "regular", module)
 		require.NoError(t, err)
 		injectors = append(injectors, inj)
 	}
@@ -0,0 +1,214 @@
+/*
+Package injecthttp integrates inject with net/http.
+
+The Middleware enters a request scope (see inject.ContextScope) for every
+request and makes the request, the response writer and the injector available
+in the request context. Install the module returned by NewModule in the
+injector in order to inject the *http.Request and the http.ResponseWriter of the
+current request:
+
+	injector, err := inject.NewInjector(appModule, injecthttp.NewModule())
+	...
+	mux := http.NewServeMux()
+	mux.Handle("/orders", injecthttp.Handler(func(w http.ResponseWriter, r *http.Request, orders *OrderService) error {
+		...
+	}))
+	http.ListenAndServe(":8080", injecthttp.Middleware(injector)(mux))
+*/
+package injecthttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/eluv-io/inject-go"
+)
+
+// ErrorHandler is called by handlers created with Handler if the injection of
+// the arguments fails or the function returns an error.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// defaultErrorHandler responds with "500 Internal Server Error".
+func defaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// Option configures the Middleware or a Handler.
+type Option func(*options)
+
+type options struct {
+	errorHandler ErrorHandler
+}
+
+func newOptions(opts []Option) *options {
+	res := &options{}
+	for _, opt := range opts {
+		opt(res)
+	}
+	return res
+}
+
+// WithErrorHandler sets the error handler of handlers created with Handler.
+// Set on the Middleware, it applies to all handlers serving its requests,
+// unless a handler is created with its own error handler. By default, errors
+// are answered with "500 Internal Server Error".
+func WithErrorHandler(errorHandler ErrorHandler) Option {
+	return func(o *options) {
+		o.errorHandler = errorHandler
+	}
+}
+
+type requestKey struct{}
+
+// handlerRequestKey is the context key of the request passed to a Handler.
+type handlerRequestKey struct{}
+
+// handlerResponseWriterKey is the context key of the response writer passed to
+// a Handler.
+type handlerResponseWriterKey struct{}
+
+// request holds the values of a request that are made available by the
+// Middleware.
+type request struct {
+	injector     inject.Injector
+	w            http.ResponseWriter
+	r            *http.Request
+	errorHandler ErrorHandler
+}
+
+// Middleware returns a middleware that enters a new request scope for every
+// request and stores the given injector, the request and the response writer
+// in the request context. The scope is exited when the request is done, which
+// stops the instances of bindings in the inject.ContextScope, even if the
+// request context is already cancelled because the client disconnected.
+func Middleware(injector inject.Injector, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			req := &request{injector: injector, w: w, errorHandler: o.errorHandler}
+			ctx := context.WithValue(inject.EnterScope(r.Context()), requestKey{}, req)
+			defer func() { _ = inject.ExitScope(ctx) }()
+			req.r = r.WithContext(ctx)
+			next.ServeHTTP(w, req.r)
+		})
+	}
+}
+
+// InjectorFromContext returns the injector stored in the given request context
+// by the Middleware.
+func InjectorFromContext(ctx context.Context) (inject.Injector, bool) {
+	req, ok := ctx.Value(requestKey{}).(*request)
+	if !ok {
+		return nil, false
+	}
+	return req.injector, true
+}
+
+// NewModule returns a module that binds the *http.Request and the
+// http.ResponseWriter of the current request, as stored in the request
+// context by the Middleware. Handlers created with Handler get the request and
+// the response writer they are called with, including any changes made by
+// handlers in between, e.g. a context derived with WithContext or a wrapped
+// response writer. Requesting them outside of a request fails.
+func NewModule() inject.Module {
+	module := inject.NewModule()
+	module.BindConstructor(newRequest)
+	module.BindConstructor(newResponseWriter)
+	return module
+}
+
+func newRequest(ctx context.Context) (*http.Request, error) {
+	if r, ok := ctx.Value(handlerRequestKey{}).(*http.Request); ok {
+		return r, nil
+	}
+	req, err := requestFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return req.r, nil
+}
+
+func newResponseWriter(ctx context.Context) (http.ResponseWriter, error) {
+	if w, ok := ctx.Value(handlerResponseWriterKey{}).(http.ResponseWriter); ok {
+		return w, nil
+	}
+	req, err := requestFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return req.w, nil
+}
+
+func requestFromContext(ctx context.Context) (*request, error) {
+	req, ok := ctx.Value(requestKey{}).(*request)
+	if !ok {
+		return nil, fmt.Errorf("injecthttp: not in a request, use the Middleware")
+	}
+	return req, nil
+}
+
+// Handler returns an http.Handler that calls the given function for every
+// request, with its arguments injected like Injector.Call does, using the
+// injector and the request context stored by the Middleware. The function
+// typically declares the http.ResponseWriter and the *http.Request as
+// arguments, in addition to the services it needs.
+//
+// The function may return an http.Handler, which then serves the request,
+// and/or an error as last return value, which is passed to the error handler
+// (see WithErrorHandler).
+//
+// Handler panics if the given value is not a function.
+func Handler(function interface{}, opts ...Option) http.Handler {
+	funcReflectType := reflect.TypeOf(function)
+	if funcReflectType == nil || funcReflectType.Kind() != reflect.Func {
+		panic(fmt.Sprintf("injecthttp: handler must be a function, got %T", function))
+	}
+	o := newOptions(opts)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := requestFromContext(r.Context())
+		if err != nil {
+			o.handleError(nil, w, r, fmt.Errorf("injecthttp: no injector in request context, use the Middleware"))
+			return
+		}
+		ctx := context.WithValue(r.Context(), handlerRequestKey{}, r)
+		ctx = context.WithValue(ctx, handlerResponseWriterKey{}, w)
+		values, err := req.injector.CallContext(ctx, function)
+		if err != nil {
+			o.handleError(req, w, r, err)
+			return
+		}
+		o.serveResults(req, w, r, values)
+	})
+}
+
+// handleError passes the given error to the error handler of the Handler, or
+// else to the one of the Middleware that stored the given request, if any.
+func (o *options) handleError(req *request, w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case o.errorHandler != nil:
+		o.errorHandler(w, r, err)
+	case req != nil && req.errorHandler != nil:
+		req.errorHandler(w, r, err)
+	default:
+		defaultErrorHandler(w, r, err)
+	}
+}
+
+// serveResults handles the values returned by a handler function.
+func (o *options) serveResults(req *request, w http.ResponseWriter, r *http.Request, values []interface{}) {
+	if len(values) == 0 {
+		return
+	}
+	if err, ok := values[len(values)-1].(error); ok {
+		o.handleError(req, w, r, err)
+		return
+	}
+	for _, value := range values {
+		if handler, ok := value.(http.Handler); ok {
+			handler.ServeHTTP(w, r)
+			return
+		}
+	}
+}
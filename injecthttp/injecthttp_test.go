@@ -0,0 +1,201 @@
+package injecthttp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/eluv-io/inject-go"
+	"github.com/eluv-io/inject-go/injecthttp"
+)
+
+type greeter struct {
+	greeting string
+}
+
+// requestLog is bound in the request scope and closed at the end of each
+// request.
+type requestLog struct {
+	r      *http.Request
+	closed *[]string
+}
+
+func (l *requestLog) Close() error {
+	*l.closed = append(*l.closed, l.r.URL.Path)
+	return nil
+}
+
+func newServer(t *testing.T, closed *[]string) http.Handler {
+	module := inject.NewModule()
+	module.BindSingleton(&greeter{"Hello"})
+	module.BindConstructor(func(r *http.Request) *requestLog {
+		return &requestLog{r, closed}
+	}).In(inject.ContextScope)
+	injector, err := inject.NewInjector(module, injecthttp.NewModule())
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.Handle("/hello", injecthttp.Handler(func(w http.ResponseWriter, r *http.Request, g *greeter, log *requestLog, log2 *requestLog) {
+		require.True(t, log == log2)
+		fmt.Fprintf(w, "%s, %s!", g.greeting, r.URL.Query().Get("name"))
+	}))
+	mux.Handle("/handler", injecthttp.Handler(func(g *greeter) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, g.greeting)
+		})
+	}))
+	mux.Handle("/error", injecthttp.Handler(func(log *requestLog) error {
+		return errors.New("failed")
+	}))
+	mux.Handle("/injector", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inj, ok := injecthttp.InjectorFromContext(r.Context())
+		require.True(t, ok)
+		require.True(t, inj == injector)
+	}))
+	return injecthttp.Middleware(injector)(mux)
+}
+
+func serve(handler http.Handler, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	var closed []string
+	server := newServer(t, &closed)
+
+	w := serve(server, "/hello?name=Alice")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "Hello, Alice!", w.Body.String())
+
+	w = serve(server, "/handler")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "Hello", w.Body.String())
+
+	w = serve(server, "/error")
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+
+	w = serve(server, "/injector")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	require.Equal(t, []string{"/hello", "/error"}, closed)
+}
+
+func TestHandlerWithoutMiddleware(t *testing.T) {
+	w := serve(injecthttp.Handler(func(g *greeter) {}), "/")
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+type userKey struct{}
+
+func TestHandlerRequest(t *testing.T) {
+	injector, err := inject.NewInjector(injecthttp.NewModule())
+	require.NoError(t, err)
+
+	// a middleware after the injecthttp.Middleware derives the request context
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, "alice")))
+		})
+	}
+	handler := injecthttp.Handler(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %v", r.URL.Path, r.Context().Value(userKey{}))
+	})
+	server := injecthttp.Middleware(injector)(auth(http.StripPrefix("/api", handler)))
+
+	w := serve(server, "/api/orders")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "/orders alice", w.Body.String())
+}
+
+func TestErrorHandler(t *testing.T) {
+	injector, err := inject.NewInjector(injecthttp.NewModule())
+	require.NoError(t, err)
+	errorHandler := func(code int) injecthttp.ErrorHandler {
+		return func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), code)
+		}
+	}
+	failing := func() error {
+		return errors.New("failed")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/middleware", injecthttp.Handler(failing))
+	mux.Handle("/handler", injecthttp.Handler(failing, injecthttp.WithErrorHandler(errorHandler(http.StatusBadRequest))))
+	server := injecthttp.Middleware(injector, injecthttp.WithErrorHandler(errorHandler(http.StatusServiceUnavailable)))(mux)
+
+	w := serve(server, "/middleware")
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Equal(t, "failed\n", w.Body.String())
+
+	w = serve(server, "/handler")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(mux, "/handler")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "use the Middleware")
+}
+
+// statusRecorder is a response writer wrapped by a middleware.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func TestHandlerResponseWriter(t *testing.T) {
+	injector, err := inject.NewInjector(injecthttp.NewModule())
+	require.NoError(t, err)
+
+	// a middleware after the injecthttp.Middleware wraps the response writer
+	var recorder *statusRecorder
+	record := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorder = &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, r)
+		})
+	}
+	handler := injecthttp.Handler(func(w http.ResponseWriter) {
+		require.True(t, w == http.ResponseWriter(recorder))
+		w.WriteHeader(http.StatusAccepted)
+	})
+	server := injecthttp.Middleware(injector)(record(handler))
+
+	w := serve(server, "/")
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Equal(t, http.StatusAccepted, recorder.status)
+}
+
+func TestMiddlewareCancelledRequest(t *testing.T) {
+	var closed []string
+	module := inject.NewModule()
+	module.BindConstructor(func(r *http.Request) *requestLog {
+		return &requestLog{r, &closed}
+	}).In(inject.ContextScope)
+	injector, err := inject.NewInjector(module, injecthttp.NewModule())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler := injecthttp.Handler(func(log *requestLog) {
+		// the client disconnects before the request scope is exited
+		cancel()
+	})
+	server := injecthttp.Middleware(injector)(handler)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cancelled", nil).WithContext(ctx))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, []string{"/cancelled"}, closed)
+}
@@ -1,79 +1,205 @@
 package inject
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"runtime"
 	"strconv"
+	"sync"
 )
 
 var injectorReflectType = reflect.TypeOf((*Injector)(nil))
 
 type injector struct {
+	*injectorState
+	// the call of the constructor or function that this injector was injected
+	// into, if any. Requests made through the injector while the call is
+	// running continue on the resolution path of the call.
+	call *resolution
+}
+
+// injectorState is the state of an injector. It is shared with the views of
+// the injector that are injected into constructors and functions.
+type injectorState struct {
 	// the injector's name
 	name string
 	// the parent injector for child injectors or nil otherwise
 	parent *injector
 	// resolved bindings
 	bindings map[bindingKey]resolvedBinding
-}
-
-func newInjector(name string, modules ...Module) (*injector, error) {
-	injector := &injector{
+	// the instantiated singletons that need to be stopped on Close
+	lifecycle lifecycle
+	// the eager per-child singletons, created with each child injector
+	perChildEager []*singletonBuilder
+	// whether no constructor of this injector may return nil values
+	rejectNilResults bool
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// the child injectors that have singletons or cleanup functions to stop
+	// and have not been closed yet
+	children map[*injectorState]*injector
+	// whether Close has been called
+	closed bool
+}
+
+func newInjector(c context.Context, name string, modules ...Module) (*injector, error) {
+	injector := &injector{injectorState: &injectorState{
 		name:     name,
 		bindings: make(map[bindingKey]resolvedBinding),
+	}}
+	return injector.init(c, modules)
+}
+
+func (inj *injector) init(c context.Context, modules []Module) (*injector, error) {
+	eager, err := inj.install(modules)
+	if err != nil {
+		return nil, err
 	}
-	return injector.init(modules)
+	if err := inj.validate(newCtx(c, inj)); err != nil {
+		return nil, err
+	}
+	if err := inj.createEager(c, eager); err != nil {
+		return nil, err
+	}
+	return inj, nil
 }
 
-func (inj *injector) init(modules []Module) (*injector, error) {
+// install installs the given modules and the binding of the injector itself,
+// and returns the eager singletons to be created.
+func (inj *injector) install(modules []Module) ([]*singletonBuilder, error) {
 	modules = append(modules, inj.createInjectorModule())
 	var eager []*singletonBuilder
+	if inj.parent != nil {
+		eager = append(eager, inj.parent.perChildEager...)
+	}
+	for _, m := range modules {
+		if castModule, ok := m.(*module); ok && castModule.injectorRejectsNil {
+			inj.rejectNilResults = true
+		}
+	}
+	multi := multibindings{}
 	for _, m := range modules {
 		castModule, ok := m.(*module)
 		if !ok {
 			return nil, errCannotCastModule
 		}
-		if err := inj.installModule(castModule); err != nil {
+		if err := inj.installModule(castModule, multi); err != nil {
 			return nil, err
 		}
-		eager = append(eager, castModule.eager...)
+		for _, e := range castModule.eager {
+			if e.options != nil && e.options.perChild {
+				inj.perChildEager = append(inj.perChildEager, e)
+			} else {
+				eager = append(eager, e)
+			}
+		}
 	}
-	if err := inj.validate(newCtx(inj)); err != nil {
-		return nil, err
+	for bindingKey, multiBinding := range multi {
+		if err := inj.installBinding(nil, bindingKey, multiBinding); err != nil {
+			return nil, err
+		}
 	}
+	if inj.parent != nil {
+		inj.resolvePerChildBindings()
+	}
+	return eager, nil
+}
 
+// createEager creates the given eager singletons, closing the injector if
+// that fails.
+func (inj *injector) createEager(c context.Context, eager []*singletonBuilder) error {
 	for _, e := range eager {
-		if e.t != nil {
-			// create the singleton
-			_, err := inj.get(newBindingKey(e.t))
-			if err != nil {
-				return nil, err
-			}
+		if err := inj.initEager(c, e); err != nil {
+			return inj.rollback(err)
 		}
-		if e.fn != nil {
-			res, err := inj.Call(e.fn)
-			if err != nil {
-				return nil, err
-			}
-			if len(res) > 0 {
-				if resErr, isErr := res[len(res)-1].(error); isErr {
-					// the last return argument is a non-nil error - return that!
-					return nil, resErr
-				}
+	}
+	return nil
+}
+
+// initEager creates the given eager singleton and calls its eager function, if
+// any. Errors are wrapped, so that the original error remains available with
+// errors.Is and errors.As.
+func (inj *injector) initEager(c context.Context, e *singletonBuilder) error {
+	if e.t != nil {
+		// create the singleton
+		_, err := inj.get(newRuntimeCtx(c, inj), newBindingKey(e.t))
+		if err != nil {
+			return errEagerFailed.withTag("err", err, true).withTag("eager", newBindingKey(e.t), true)
+		}
+	}
+	if e.fn != nil {
+		res, err := inj.CallContext(c, e.fn)
+		if err != nil {
+			return errEagerFailed.withTag("err", err, true).withTag("eager", functionTag(e.fn), true)
+		}
+		if len(res) > 0 {
+			if resErr, isErr := res[len(res)-1].(error); isErr {
+				// the last return argument is a non-nil error - return that!
+				return errEagerFailed.withTag("err", resErr, true).withTag("eager", functionTag(e.fn), true)
 			}
 		}
 	}
-	return inj, nil
+	return nil
+}
+
+// rollback closes the injector after a failed initialization, stopping all
+// singletons that were created up to the failure, and returns the given
+// initialization error.
+func (inj *injector) rollback(err error) error {
+	if closeErr := inj.Close(context.Background()); closeErr != nil {
+		return unwrap(err).withTag("rollback", closeErr.Error(), true)
+	}
+	return err
 }
 
 func (inj *injector) createInjectorModule() Module {
-	m := NewModule()
-	m.Bind((*Injector)(nil)).ToSingleton(inj)
+	m := newModule()
+	m.setBinding(newBindingKey(injectorReflectType), &injectorBinding{inj})
 	return m
 }
 
-func (inj *injector) installModule(module *module) error {
+// withCall returns a view of this injector for the given constructor or
+// function call, or for no call at all.
+func (inj *injector) withCall(call *resolution) *injector {
+	return &injector{injectorState: inj.injectorState, call: call}
+}
+
+// injectorBinding provides the injector itself. A constructor or function
+// called by the injector receives a view of the injector for that call:
+// requests made through the view while the call is running continue on the
+// resolution path of the call, from any goroutine. A request that leads back
+// to a value being constructed on that path fails with a circular dependency
+// error instead of deadlocking.
+type injectorBinding struct {
+	injector *injector
+}
+
+func (i *injectorBinding) String() string {
+	return i.injector.name
+}
+
+func (i *injectorBinding) resolvedBinding(*module, *injector, bindingKey) (resolvedBinding, error) {
+	return i, nil
+}
+
+func (i *injectorBinding) validate(ctx) error {
+	return nil
+}
+
+func (i *injectorBinding) get(ctx ctx) (interface{}, error) {
+	if ctx.call == nil || ctx.call.ctx.current.parent == nil {
+		// calls at the root of a resolution, e.g. of Injector.Call, cannot
+		// lead back to a value being constructed
+		return i.injector, nil
+	}
+	return i.injector.withCall(ctx.call), nil
+}
+
+// installModule installs the bindings of the given module, except for its
+// multibindings, which are added to the given multibindings of all modules.
+func (inj *injector) installModule(module *module, multi multibindings) error {
 	numBindingErrors := len(module.bindingErrors)
 	if numBindingErrors > 0 {
 		err := errBindingErrors
@@ -83,24 +209,50 @@ func (inj *injector) installModule(module *module) error {
 		return err
 	}
 	for bindingKey, binding := range module.bindings {
-		if foundBinding, ok := inj.bindings[bindingKey]; ok {
-			return errAlreadyBound.withTag("bindingKey", bindingKey).withTag("foundBinding", foundBinding)
+		if multi.add(bindingKey, binding, module) {
+			continue
 		}
-		// check parent bindings, but allow replacing the binding of the injector
-		if inj.parent != nil && bindingKey.reflectType() != injectorReflectType {
-			if foundBinding, ok := inj.parent.bindings[bindingKey]; ok {
-				return errAlreadyBound.withTag("bindingKey", bindingKey).withTag("foundBinding", foundBinding).withTag("scope", "parent")
-			}
-		}
-		resolvedBinding, err := binding.resolvedBinding(module, inj)
-		if err != nil {
+		if err := inj.installBinding(module, bindingKey, binding); err != nil {
 			return err
 		}
-		inj.bindings[bindingKey] = resolvedBinding
 	}
 	return nil
 }
 
+func (inj *injector) installBinding(module *module, bindingKey bindingKey, binding binding) error {
+	resolvedBinding, err := binding.resolvedBinding(module, inj, bindingKey)
+	if err != nil {
+		return err
+	}
+	if foundBinding, ok := inj.bindings[bindingKey]; ok {
+		// default bindings yield to regular bindings
+		replaced, ok := replaceResolvedDefault(foundBinding, resolvedBinding)
+		if !ok {
+			return errAlreadyBound.withTag("bindingKey", bindingKey).withTag("foundBinding", foundBinding)
+		}
+		resolvedBinding = replaced
+	} else if inj.parent != nil && bindingKey.reflectType() != injectorReflectType {
+		// check parent bindings, but allow replacing the binding of the
+		// injector. Default bindings of the parent cannot be replaced, since
+		// the singletons of the parent may depend on them already.
+		if foundBinding, ok := inj.parent.bindings[bindingKey]; ok {
+			return errAlreadyBound.withTag("bindingKey", bindingKey).withTag("foundBinding", foundBinding).withTag("scope", "parent")
+		}
+	}
+	inj.bindings[bindingKey] = resolvedBinding
+	return nil
+}
+
+// resolvePerChildBindings creates the local bindings of this child injector for
+// the per-child singletons of its parent.
+func (inj *injector) resolvePerChildBindings() {
+	for bindingKey, binding := range inj.parent.bindings {
+		if perChild, ok := asPerChild(binding); ok {
+			inj.bindings[bindingKey] = perChild.resolve(inj)
+		}
+	}
+}
+
 func (inj *injector) validate(ctx ctx) error {
 	for key, resolvedBinding := range inj.bindings {
 		if err := ctx.push(key, resolvedBinding); err != nil {
@@ -139,11 +291,18 @@ func (inj *injector) keyValueStrings() []string {
 }
 
 func (inj *injector) Get(from interface{}) (interface{}, error) {
-	return inj.get(newBindingKey(reflect.TypeOf(from)))
+	return inj.GetContext(context.Background(), from)
+}
+
+func (inj *injector) GetContext(c context.Context, from interface{}) (interface{}, error) {
+	return inj.get(newRuntimeCtx(c, inj), newBindingKey(reflect.TypeOf(from)))
 }
 
 func (inj *injector) DependencyTree() (DependencyTree, error) {
-	c := newCtx(inj)
+	if err := inj.verifyNotClosed(); err != nil {
+		return nil, err
+	}
+	c := newCtx(context.Background(), inj)
 	err := inj.validate(c)
 	if err != nil {
 		return nil, err
@@ -152,7 +311,11 @@ func (inj *injector) DependencyTree() (DependencyTree, error) {
 }
 
 func (inj *injector) GetTagged(tag string, from interface{}) (interface{}, error) {
-	return inj.get(newTaggedBindingKey(reflect.TypeOf(from), tag))
+	return inj.GetTaggedContext(context.Background(), tag, from)
+}
+
+func (inj *injector) GetTaggedContext(c context.Context, tag string, from interface{}) (interface{}, error) {
+	return inj.get(newRuntimeCtx(c, inj), newTaggedBindingKey(reflect.TypeOf(from), tag))
 }
 
 func (inj *injector) GetTaggedBool(tag string) (bool, error) {
@@ -284,10 +447,14 @@ func (inj *injector) GetTaggedString(tag string) (string, error) {
 }
 
 func (inj *injector) getTaggedConstant(tag string, constantKind constantKind) (interface{}, error) {
-	return inj.get(newTaggedBindingKey(constantKind.reflectType(), tag))
+	return inj.get(newRuntimeCtx(context.Background(), inj), newTaggedBindingKey(constantKind.reflectType(), tag))
 }
 
 func (inj *injector) Call(function interface{}) ([]interface{}, error) {
+	return inj.CallContext(context.Background(), function)
+}
+
+func (inj *injector) CallContext(c context.Context, function interface{}) ([]interface{}, error) {
 	funcReflectType := reflect.TypeOf(function)
 	if err := verifyIsFunc(funcReflectType); err != nil {
 		return nil, err
@@ -296,15 +463,24 @@ func (inj *injector) Call(function interface{}) ([]interface{}, error) {
 	if err := inj.validateBindingKeys(bindingKeys); err != nil {
 		return nil, unwrap(err).withTag("funcReflectType", funcReflectType)
 	}
-	reflectValues, err := inj.getReflectValues(bindingKeys)
+	ctx := newRuntimeCtx(c, inj)
+	defer ctx.startCall()()
+	reflectValues, err := inj.getReflectValues(ctx, bindingKeys)
 	if err != nil {
 		return nil, unwrap(err).withTag("funcReflectType", funcReflectType)
 	}
-	returnValues := reflect.ValueOf(function).Call(reflectValues)
+	returnValues, err := callFunction(ctx, function, reflectValues)
+	if err != nil {
+		return nil, err
+	}
 	return reflectValuesToValues(returnValues), nil
 }
 
 func (inj *injector) CallTagged(taggedFunction interface{}) ([]interface{}, error) {
+	return inj.CallTaggedContext(context.Background(), taggedFunction)
+}
+
+func (inj *injector) CallTaggedContext(c context.Context, taggedFunction interface{}) ([]interface{}, error) {
 	taggedFuncReflectType := reflect.TypeOf(taggedFunction)
 	if err := verifyIsTaggedFunc(taggedFuncReflectType); err != nil {
 		return nil, err
@@ -313,13 +489,18 @@ func (inj *injector) CallTagged(taggedFunction interface{}) ([]interface{}, erro
 	if err := inj.validateBindingKeys(bindingKeys); err != nil {
 		return nil, unwrap(err).withTag("funcReflectType", taggedFuncReflectType)
 	}
-	reflectValues, err := inj.getReflectValues(bindingKeys)
+	ctx := newRuntimeCtx(c, inj)
+	defer ctx.startCall()()
+	reflectValues, err := inj.getReflectValues(ctx, bindingKeys)
 	if err != nil {
 		return nil, unwrap(err).withTag("funcReflectType", taggedFuncReflectType)
 	}
 	structReflectValue := newStructReflectValue(taggedFuncReflectType.In(0))
 	populateStructReflectValue(&structReflectValue, reflectValues)
-	returnValues := reflect.ValueOf(taggedFunction).Call([]reflect.Value{structReflectValue})
+	returnValues, err := callFunction(ctx, taggedFunction, []reflect.Value{structReflectValue})
+	if err != nil {
+		return nil, err
+	}
 	return reflectValuesToValues(returnValues), nil
 }
 
@@ -336,7 +517,7 @@ func (inj *injector) Populate(populateStructPtr interface{}) error {
 	if err := inj.validateBindingKeys(bindingKeys); err != nil {
 		return unwrap(err).withTag("funcReflectType", populateStructPtr)
 	}
-	reflectValues, err := inj.getReflectValues(bindingKeys)
+	reflectValues, err := inj.getReflectValues(newRuntimeCtx(context.Background(), inj), bindingKeys)
 	if err != nil {
 		return unwrap(err).withTag("funcReflectType", populateStructPtr)
 	}
@@ -344,57 +525,200 @@ func (inj *injector) Populate(populateStructPtr interface{}) error {
 	return nil
 }
 
+func (inj *injector) Close(ctx context.Context) error {
+	inj.mu.Lock()
+	inj.closed = true
+	children := make([]*injector, 0, len(inj.children))
+	for _, child := range inj.children {
+		children = append(children, child)
+	}
+	inj.mu.Unlock()
+
+	// the singletons of child injectors may depend on singletons of this
+	// injector, so they are stopped first
+	var errs []error
+	for _, child := range children {
+		if err := child.Close(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := inj.lifecycle.close(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	if inj.parent != nil {
+		inj.parent.removeChild(inj)
+	}
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	err := errCloseFailed
+	for i, e := range errs {
+		err = err.withTag(strconv.Itoa(i+1), e.Error(), true)
+	}
+	return err
+}
+
+// verifyNotClosed returns an error if this injector or any of its ancestors
+// has been closed.
+func (inj *injector) verifyNotClosed() error {
+	for i := inj; i != nil; i = i.parent {
+		i.mu.Lock()
+		closed := i.closed
+		i.mu.Unlock()
+		if closed {
+			return errInjectorClosed.withTag("injector", i.name, true)
+		}
+	}
+	return nil
+}
+
+func (inj *injector) addChild(child *injector) error {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	if inj.closed {
+		return errInjectorClosed.withTag("injector", inj.name, true)
+	}
+	if inj.children == nil {
+		inj.children = make(map[*injectorState]*injector)
+	}
+	inj.children[child.injectorState] = child
+	return nil
+}
+
+func (inj *injector) removeChild(child *injector) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	delete(inj.children, child.injectorState)
+}
+
 func (inj *injector) NewChildInjector(overridesType interface{}, modules ...Module) (Injector, error) {
 	name := callerName(3, "child")
 	return inj.NewNamedChildInjector(name, overridesType, modules...)
 }
 
 func (inj *injector) NewNamedChildInjector(name string, overridesType interface{}, modules ...Module) (Injector, error) {
-	overrides, _ := inj.Get(overridesType)
-	if om, ok := overrides.(Module); ok {
-		modules = []Module{Override(modules...).With(om)}
-	}
-	injector := &injector{
-		name:     name,
-		parent:   inj,
-		bindings: make(map[bindingKey]resolvedBinding),
+	if err := inj.verifyNotClosed(); err != nil {
+		return nil, err
 	}
-	_, err := injector.init(modules)
+	injector := inj.newChild(name)
+	_, err := injector.init(context.Background(), inj.childModules(overridesType, modules))
 	if err != nil {
 		return nil, err
 	}
+	if err = inj.verifyNotClosed(); err != nil {
+		_ = injector.Close(context.Background())
+		return nil, err
+	}
 	return injector, nil
 }
 
-func (inj *injector) get(bindingKey bindingKey) (interface{}, error) {
+// newChild creates an empty child injector with the given name. This injector
+// only keeps track of the child, and closes it when it is closed itself, once
+// the child has singletons or cleanup functions to stop. Other children are
+// not retained and may be discarded without closing them.
+func (inj *injector) newChild(name string) *injector {
+	child := &injector{injectorState: &injectorState{
+		name:     name,
+		parent:   inj.withCall(nil),
+		bindings: make(map[bindingKey]resolvedBinding),
+		// the children of an injector that rejects nil results reject them
+		// as well
+		rejectNilResults: inj.rejectNilResults,
+	}}
+	child.lifecycle.onTrack = func() { _ = child.parent.register(child) }
+	return child
+}
+
+// childModules returns the given modules of a child injector, overridden by
+// the module bound to the given overrides type, if any.
+func (inj *injector) childModules(overridesType interface{}, modules []Module) []Module {
+	overrides, _ := inj.Get(overridesType)
+	if om, ok := overrides.(Module); ok {
+		return []Module{Override(modules...).With(om)}
+	}
+	return modules
+}
+
+// register adds the given child injector to this injector, or closes the child
+// if this injector has been closed in the meantime.
+func (inj *injector) register(child *injector) error {
+	if err := inj.addChild(child); err != nil {
+		_ = child.Close(context.Background())
+		return err
+	}
+	return nil
+}
+
+// get returns the value for the given binding key. The given dependency
+// resolution context describes the path that lead to this binding key.
+func (inj *injector) get(ctx ctx, bindingKey bindingKey) (interface{}, error) {
+	if err := inj.verifyNotClosed(); err != nil {
+		return nil, err
+	}
+	if err := ctx.verifyNotDone(); err != nil {
+		return nil, unwrap(err).withTag("bindingKey", bindingKey)
+	}
 	binding, err := inj.getBinding(bindingKey)
 	if err != nil {
 		return nil, err
 	}
-	return binding.get()
+	if err := ctx.push(bindingKey, binding); err != nil {
+		return nil, err
+	}
+	// dependencies use the lifecycle of their injector unless their scope
+	// says otherwise
+	ctx.lifecycle = nil
+	return binding.get(ctx)
+}
+
+func (inj *injector) getBinding(bindingKey bindingKey) (resolvedBinding, error) {
+	if binding, ok := inj.lookupBinding(bindingKey); ok {
+		return binding, nil
+	}
+	// implicit bindings are resolved in this injector rather than the parent,
+	// since they may depend on the bindings of this injector
+	if bindingKey == contextBindingKey {
+		return contextBinding{}, nil
+	}
+	if binding := newTaggedMapBinding(inj, bindingKey); binding != nil {
+		return binding, nil
+	}
+	return nil, errNoBinding.withTag("bindingKey", bindingKey)
 }
 
-func (inj *injector) getBinding(bindingKey bindingKey, nostack ...bool) (resolvedBinding, error) {
-	// get binding from parent, if any, but not the injector itself
+// lookupBinding returns the binding for the given key that is declared in this
+// injector or its ancestors.
+func (inj *injector) lookupBinding(bindingKey bindingKey) (resolvedBinding, bool) {
+	// get binding from parent, if any, but not the injector itself nor the
+	// per-child singletons of the parent, which are resolved locally
 	if inj.parent != nil && bindingKey.reflectType() != injectorReflectType {
-		binding, err := inj.parent.getBinding(bindingKey, true)
-		if err == nil {
-			return binding, nil
+		binding, ok := inj.parent.lookupBinding(bindingKey)
+		if _, perChild := asPerChild(binding); ok && !perChild {
+			return binding, true
 		}
 	}
 	// get local binding
 	binding, ok := inj.bindings[bindingKey]
-	if !ok {
-		return nil, errNoBinding.withTag("bindingKey", bindingKey, nostack...)
-	}
-	return binding, nil
+	return binding, ok
 }
 
-func (inj *injector) getReflectValues(bindingKeys []bindingKey) ([]reflect.Value, error) {
+func (inj *injector) getReflectValues(ctx ctx, bindingKeys []bindingKey) ([]reflect.Value, error) {
 	numBindingKeys := len(bindingKeys)
 	reflectValues := make([]reflect.Value, numBindingKeys)
 	for ii := 0; ii < numBindingKeys; ii++ {
-		value, err := inj.get(bindingKeys[ii])
+		if optional, ok := bindingKeys[ii].(optionalBindingKey); ok {
+			reflectValue, err := inj.getOptional(ctx, optional)
+			if err != nil {
+				return nil, err
+			}
+			reflectValues[ii] = reflectValue
+			continue
+		}
+		value, err := inj.get(ctx, bindingKeys[ii])
 		if err != nil {
 			return nil, err
 		}
@@ -403,8 +727,23 @@ func (inj *injector) getReflectValues(bindingKeys []bindingKey) ([]reflect.Value
 	return reflectValues, nil
 }
 
+// getOptional returns the value of the given optional dependency.
+func (inj *injector) getOptional(ctx ctx, bindingKey optionalBindingKey) (reflect.Value, error) {
+	if _, err := inj.getBinding(bindingKey.bindingKey); err != nil {
+		return bindingKey.absent(), nil
+	}
+	value, err := inj.get(ctx, bindingKey.bindingKey)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return bindingKey.present(value), nil
+}
+
 func (inj *injector) validateBindingKeys(bindingKeys []bindingKey) error {
 	for _, bindingKey := range bindingKeys {
+		if _, optional := bindingKey.(optionalBindingKey); optional {
+			continue
+		}
 		if _, err := inj.getBinding(bindingKey); err != nil {
 			return err
 		}
@@ -415,6 +754,13 @@ func (inj *injector) validateBindingKeys(bindingKeys []bindingKey) error {
 // validate all bindings for the given binding keys recursively
 func (inj *injector) validateBindings(ctx ctx, bindingKeys []bindingKey) error {
 	for _, bindingKey := range bindingKeys {
+		if optional, ok := bindingKey.(optionalBindingKey); ok {
+			// optional dependencies are satisfied if they are not bound
+			bindingKey = optional.bindingKey
+			if _, err := inj.getBinding(bindingKey); err != nil {
+				continue
+			}
+		}
 		resolvedBinding, err := inj.getBinding(bindingKey)
 		if err != nil {
 			return err
@@ -0,0 +1,195 @@
+package inject
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// Stopper is implemented by singletons that need to release resources (close
+// connection pools, stop background goroutines, ...) when the injector that
+// created them is closed. See Injector.Close.
+//
+// Singletons implementing io.Closer are closed as well. If a singleton
+// implements both interfaces, only Stop is called.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// lifecycle keeps track of the instantiated singletons of an injector that
+// need to be stopped and the cleanup functions returned by constructors that
+// need to be called when the injector is closed.
+type lifecycle struct {
+	mu      sync.Mutex
+	entries []*lifecycleEntry
+	// whether close has been called, after which entries are stopped as soon
+	// as they are added
+	closed bool
+	// called when the first entry is added, if set
+	onTrack func()
+}
+
+type lifecycleEntry struct {
+	// the name of the constructor that created the instance
+	name     string
+	instance interface{}
+	cleanup  func()
+}
+
+// track registers the given singleton instance if it implements Starter,
+// Stopper or io.Closer. Instances are tracked in creation order, which is also their
+// dependency order, since the dependencies of a singleton are always created
+// before the singleton itself.
+func (l *lifecycle) track(name string, instance interface{}) {
+	switch instance.(type) {
+	case Starter, Stopper, io.Closer:
+	default:
+		return
+	}
+	l.add(&lifecycleEntry{name: name, instance: instance})
+}
+
+// trackCleanup registers the cleanup function returned by the constructor with
+// the given name.
+func (l *lifecycle) trackCleanup(name string, cleanup func()) {
+	l.add(&lifecycleEntry{name: name, cleanup: cleanup})
+}
+
+func (l *lifecycle) add(entry *lifecycleEntry) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		_ = entry.stop(context.Background())
+		return
+	}
+	first := len(l.entries) == 0
+	l.entries = append(l.entries, entry)
+	l.mu.Unlock()
+	if first && l.onTrack != nil {
+		l.onTrack()
+	}
+}
+
+// start starts all tracked instances implementing Starter in creation order.
+// Singletons that are instantiated while starting are started as well. If an
+// instance fails to start, the instances that have been started successfully
+// are returned along with the error.
+func (l *lifecycle) start(ctx context.Context) ([]*lifecycleEntry, error) {
+	var started []*lifecycleEntry
+	for i := 0; ; i++ {
+		l.mu.Lock()
+		if i >= len(l.entries) {
+			l.mu.Unlock()
+			return started, nil
+		}
+		entry := l.entries[i]
+		l.mu.Unlock()
+
+		starter, ok := entry.instance.(Starter)
+		if !ok {
+			continue
+		}
+		if err := starter.Start(ctx); err != nil {
+			return started, errStartFailed.withTag("err", err, true).withTag("constructor", entry.name, true)
+		}
+		started = append(started, entry)
+	}
+}
+
+// forgetUnstarted stops tracking the instances implementing Starter that are
+// not among the given started instances, after a start failure, so that
+// closing the lifecycle does not stop instances that have never been started.
+// Instances that do not implement Starter and cleanup functions stay tracked.
+func (l *lifecycle) forgetUnstarted(started []*lifecycleEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries := make([]*lifecycleEntry, 0, len(l.entries))
+	for _, entry := range l.entries {
+		if _, ok := entry.instance.(Starter); !ok || containsEntry(started, entry) {
+			entries = append(entries, entry)
+		}
+	}
+	l.entries = entries
+}
+
+func containsEntry(entries []*lifecycleEntry, entry *lifecycleEntry) bool {
+	for _, e := range entries {
+		if e == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// close stops all tracked instances in reverse creation order and returns the
+// aggregated errors, if any. If the given context is done before all
+// instances have been stopped, the remaining instances stay tracked, so that
+// calling close again stops them.
+func (l *lifecycle) close(ctx context.Context) error {
+	l.mu.Lock()
+	entries := l.entries
+	l.entries = nil
+	l.closed = true
+	l.mu.Unlock()
+
+	remaining, err := stopEntries(ctx, entries)
+	if len(remaining) > 0 {
+		l.mu.Lock()
+		l.entries = append(remaining, l.entries...)
+		l.mu.Unlock()
+	}
+	return err
+}
+
+// stopEntries stops the given entries in reverse order and returns the
+// entries that have not been stopped because the given context is done, and
+// the aggregated errors, if any.
+func stopEntries(ctx context.Context, entries []*lifecycleEntry) ([]*lifecycleEntry, error) {
+	var errs []error
+	var remaining []*lifecycleEntry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if ctx.Err() != nil {
+			errs = append(errs, errCloseAborted.withTag("err", ctx.Err()).withTag("remaining", i+1))
+			remaining = entries[:i+1]
+			break
+		}
+		if err := entries[i].stop(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil, nil
+	}
+	err := errCloseFailed
+	for i, e := range errs {
+		err = err.withTag(strconv.Itoa(i+1), e.Error(), true)
+	}
+	return remaining, err
+}
+
+func (e *lifecycleEntry) stop(ctx context.Context) error {
+	if e.cleanup != nil {
+		e.cleanup()
+		return nil
+	}
+	var err error
+	switch instance := e.instance.(type) {
+	case Stopper:
+		err = instance.Stop(ctx)
+	case io.Closer:
+		err = instance.Close()
+	}
+	if err != nil {
+		return errStopFailed.withTag("err", err, true).withTag("constructor", e.name, true)
+	}
+	return nil
+}
+
+// discard stops the result of a call of the constructor with the given name
+// that was abandoned after a timeout, since it is never used.
+func discard(name string, res valueErr) {
+	if res.err == nil {
+		_ = (&lifecycleEntry{name: name, instance: res.value}).stop(context.Background())
+	}
+}
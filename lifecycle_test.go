@@ -0,0 +1,317 @@
+package inject
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stopRecorder struct {
+	stopped []string
+}
+
+type lifecycleDB struct {
+	rec *stopRecorder
+}
+
+func (d *lifecycleDB) Close() error {
+	d.rec.stopped = append(d.rec.stopped, "db")
+	return nil
+}
+
+type lifecycleCache struct {
+	rec *stopRecorder
+	db  *lifecycleDB
+}
+
+func (c *lifecycleCache) Stop(ctx context.Context) error {
+	c.rec.stopped = append(c.rec.stopped, "cache")
+	return errors.New("cache stop failed")
+}
+
+type lifecycleServer struct {
+	rec   *stopRecorder
+	cache *lifecycleCache
+}
+
+func (s *lifecycleServer) Close() error {
+	s.rec.stopped = append(s.rec.stopped, "server")
+	return nil
+}
+
+func newLifecycleModule(rec *stopRecorder) Module {
+	module := NewModule()
+	module.BindSingleton(rec)
+	module.BindSingletonConstructor(func(rec *stopRecorder) *lifecycleDB {
+		return &lifecycleDB{rec}
+	})
+	module.BindSingletonConstructor(func(rec *stopRecorder, db *lifecycleDB) *lifecycleCache {
+		return &lifecycleCache{rec, db}
+	})
+	module.BindSingletonConstructor(func(rec *stopRecorder, cache *lifecycleCache) *lifecycleServer {
+		return &lifecycleServer{rec, cache}
+	})
+	return module
+}
+
+func TestCloseStopsSingletonsInReverseOrder(t *testing.T) {
+	rec := &stopRecorder{}
+	injector, err := NewInjector(newLifecycleModule(rec))
+	require.NoError(t, err)
+
+	_, err = injector.Get(&lifecycleServer{})
+	require.NoError(t, err)
+
+	err = injector.Close(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeCloseFailed)
+	require.Contains(t, err.Error(), "cache stop failed")
+	require.Equal(t, []string{"server", "cache", "db"}, rec.stopped)
+
+	// a second close is a no-op
+	require.NoError(t, injector.Close(context.Background()))
+	require.Equal(t, []string{"server", "cache", "db"}, rec.stopped)
+}
+
+func TestCloseSkipsSingletonsNotInstantiated(t *testing.T) {
+	rec := &stopRecorder{}
+	injector, err := NewInjector(newLifecycleModule(rec))
+	require.NoError(t, err)
+
+	_, err = injector.Get(&lifecycleDB{})
+	require.NoError(t, err)
+
+	require.NoError(t, injector.Close(context.Background()))
+	require.Equal(t, []string{"db"}, rec.stopped)
+}
+
+func TestCloseWithDoneContext(t *testing.T) {
+	rec := &stopRecorder{}
+	injector, err := NewInjector(newLifecycleModule(rec))
+	require.NoError(t, err)
+
+	_, err = injector.Get(&lifecycleDB{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = injector.Close(ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeCloseAborted)
+	require.Empty(t, rec.stopped)
+
+	// the skipped singletons are stopped by the next Close
+	require.NoError(t, injector.Close(context.Background()))
+	require.Equal(t, []string{"db"}, rec.stopped)
+}
+
+type cleanupA struct{}
+
+type cleanupB struct {
+	a *cleanupA
+}
+
+type cleanupC struct {
+	b *cleanupB
+}
+
+func TestConstructorCleanupFunctions(t *testing.T) {
+	var cleaned []string
+	module := NewModule()
+	module.BindSingletonConstructor(func() (*cleanupA, func(), error) {
+		return &cleanupA{}, func() { cleaned = append(cleaned, "a") }, nil
+	})
+	module.BindSingletonConstructor(func(a *cleanupA) (*cleanupB, func()) {
+		return &cleanupB{a}, func() { cleaned = append(cleaned, "b") }
+	})
+	module.BindSingletonConstructor(func(b *cleanupB) (*cleanupC, func(), error) {
+		return nil, func() { cleaned = append(cleaned, "c") }, errors.New("c failed")
+	})
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	_, err = injector.Get(&cleanupC{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "c failed")
+
+	require.NoError(t, injector.Close(context.Background()))
+	require.Equal(t, []string{"b", "a"}, cleaned)
+}
+
+func TestConstructorCleanupFunctionsChildInjector(t *testing.T) {
+	var cleaned []string
+	parentModule := NewModule()
+	parentModule.BindSingletonConstructor(func() (*cleanupA, func()) {
+		return &cleanupA{}, func() { cleaned = append(cleaned, "a") }
+	})
+	parent, err := NewInjector(parentModule)
+	require.NoError(t, err)
+
+	childModule := NewModule()
+	childModule.BindSingletonConstructor(func(a *cleanupA) (*cleanupB, func()) {
+		return &cleanupB{a}, func() { cleaned = append(cleaned, "b") }
+	})
+	child, err := parent.NewChildInjector(nil, childModule)
+	require.NoError(t, err)
+
+	_, err = child.Get(&cleanupB{})
+	require.NoError(t, err)
+
+	require.NoError(t, child.Close(context.Background()))
+	require.Equal(t, []string{"b"}, cleaned)
+	require.NoError(t, parent.Close(context.Background()))
+	require.Equal(t, []string{"b", "a"}, cleaned)
+}
+
+func TestConstructorCleanupFunctionsNotOwned(t *testing.T) {
+	newCleanupA := func() (*cleanupA, func()) {
+		return &cleanupA{}, func() {}
+	}
+	for name, bind := range map[string]func(m Module){
+		"transient": func(m Module) {
+			m.BindConstructor(newCleanupA)
+		},
+		"tagged transient": func(m Module) {
+			m.Bind(&cleanupA{}).ToTaggedConstructor(func(struct{}) (*cleanupA, func(), error) {
+				return &cleanupA{}, func() {}, nil
+			})
+		},
+		"pool scope": func(m Module) {
+			m.BindConstructor(newCleanupA).In(NewPoolScope())
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			module := NewModule()
+			bind(module)
+			_, err := NewInjector(module)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), injectErrorTypeCleanupNotOwned)
+		})
+	}
+}
+
+func TestChildInjectorTrackedWithSingletonsToStop(t *testing.T) {
+	rec := &stopRecorder{}
+	parentModule := NewModule()
+	parentModule.BindSingleton(rec)
+	parent, err := NewInjector(parentModule)
+	require.NoError(t, err)
+	numChildren := func() int {
+		p := parent.(*injector)
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return len(p.children)
+	}
+
+	// a child without singletons to stop is not retained by its parent
+	child, err := parent.NewChildInjector(nil, NewModule())
+	require.NoError(t, err)
+	_, err = child.Get(&stopRecorder{})
+	require.NoError(t, err)
+	require.Equal(t, 0, numChildren())
+
+	childModule := NewModule()
+	childModule.BindSingletonConstructor(func() *lifecycleDB { return &lifecycleDB{rec} })
+	child, err = parent.NewChildInjector(nil, childModule)
+	require.NoError(t, err)
+	require.Equal(t, 0, numChildren())
+	_, err = child.Get(&lifecycleDB{})
+	require.NoError(t, err)
+	require.Equal(t, 1, numChildren())
+
+	require.NoError(t, parent.Close(context.Background()))
+	require.Equal(t, []string{"db"}, rec.stopped)
+	require.Equal(t, 0, numChildren())
+}
+
+func TestConstructorCleanupInvalidReturnValues(t *testing.T) {
+	module := NewModule()
+	module.BindConstructor(func() (*lifecycleDB, error, func()) {
+		return nil, nil, nil
+	})
+	_, err := NewInjector(module)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeConstructorReturnValuesInvalid)
+}
+
+type eagerFailure struct{}
+
+func TestRollbackEagerSingletonsOnFailure(t *testing.T) {
+	errEager := errors.New("cannot listen")
+	rec := &stopRecorder{}
+	module := newLifecycleModule(rec)
+	module.BindSingletonConstructor(func(cache *lifecycleCache) (*eagerFailure, error) {
+		return nil, errEager
+	}).Eagerly()
+	module.BindSingletonConstructor(func() (*cleanupA, func()) {
+		return &cleanupA{}, func() { rec.stopped = append(rec.stopped, "a") }
+	}).Eagerly()
+
+	_, err := NewInjector(module)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, errEager))
+	require.Contains(t, err.Error(), "eager:{type:*inject.eagerFailure}")
+	require.Contains(t, err.Error(), "rollback:") // from lifecycleCache.Stop
+	require.Equal(t, []string{"cache", "db"}, rec.stopped)
+}
+
+type eagerError struct{ reason string }
+
+func (e *eagerError) Error() string { return e.reason }
+
+func TestEagerErrorsReachable(t *testing.T) {
+	errNotReady := errors.New("not ready")
+	module := NewModule()
+	module.CallEagerly(func() error {
+		return errNotReady
+	})
+	_, err := NewInjector(module)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, errNotReady))
+	require.Contains(t, err.Error(), injectErrorTypeEagerFailed)
+
+	module = NewModule()
+	module.CallEagerly(func() error {
+		return &eagerError{"no port"}
+	})
+	_, err = NewInjector(module)
+	require.Error(t, err)
+	var eagerErr *eagerError
+	require.True(t, errors.As(err, &eagerErr))
+	require.Equal(t, "no port", eagerErr.reason)
+
+	// errors of the injector returned by an eager function
+	var getErr error
+	module = NewModule()
+	module.CallEagerly(func(injector Injector) error {
+		_, getErr = injector.Get(&cleanupA{})
+		return getErr
+	})
+	_, err = NewInjector(module)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, getErr))
+}
+
+func TestRollbackEagerCallOnFailure(t *testing.T) {
+	rec := &stopRecorder{}
+	parent, err := NewInjector(newLifecycleModule(rec))
+	require.NoError(t, err)
+
+	module := NewModule()
+	module.BindSingletonConstructor(func(db *lifecycleDB) (*cleanupA, func()) {
+		return &cleanupA{}, func() { rec.stopped = append(rec.stopped, "a") }
+	}).EagerlyAndCall(func(a *cleanupA) error {
+		return errors.New("call failed")
+	})
+	_, err = parent.NewChildInjector(nil, module)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "call failed")
+	require.Contains(t, err.Error(), "eager:<")
+	// only the singleton of the child is rolled back
+	require.Equal(t, []string{"a"}, rec.stopped)
+
+	require.NoError(t, parent.Close(context.Background()))
+	require.Equal(t, []string{"a", "db"}, rec.stopped)
+}
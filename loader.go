@@ -1,26 +1,105 @@
 package inject
 
 import (
-	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type loader struct {
-	once  sync.Once
-	value atomic.Value
+	// sem serializes the calls of the constructor
+	sem       chan struct{}
+	value     atomic.Value
+	name      string
+	lifecycle *lifecycle
+	retry     RetryPolicy
+	timeout   time.Duration
+	// the call of the constructor that was abandoned after a timeout and may
+	// still be running, protected by sem
+	abandoned *construction
 }
 
-func newLoader() *loader {
-	return &loader{sync.Once{}, atomic.Value{}}
+// newLoader creates a loader for the singleton created by the constructor with
+// the given name. Successfully loaded values are tracked in the given
+// lifecycle. Failed loads are retried according to the given retry policy.
+// Calls of the constructor that do not complete within the given timeout, if
+// any, are abandoned.
+func newLoader(name string, lifecycle *lifecycle, retry RetryPolicy, timeout time.Duration) *loader {
+	return &loader{sem: make(chan struct{}, 1), name: name, lifecycle: lifecycle, retry: retry, timeout: timeout}
 }
 
-func (l *loader) load(f func() (interface{}, error)) (interface{}, error) {
-	l.once.Do(func() {
-		value, err := f()
-		l.value.Store(&valueErr{value, err})
+// load returns the loaded value, calling f if it has not been loaded yet.
+// Concurrent callers wait for a single call of f, unless the given context is
+// done. Errors are not cached if the context is done, since they are most
+// likely caused by the cancellation.
+func (l *loader) load(ctx ctx, f func(ctx ctx) (interface{}, error)) (interface{}, error) {
+	if valueErr, ok := l.value.Load().(*valueErr); ok {
+		return valueErr.value, valueErr.err
+	}
+
+	c := ctx.context
+	select {
+	case l.sem <- struct{}{}:
+	case <-c.Done():
+		return nil, errContextDone.withTag("err", c.Err()).withTag("singleton", l.name, true)
+	}
+	defer func() { <-l.sem }()
+
+	if valueErr, ok := l.value.Load().(*valueErr); ok {
+		return valueErr.value, valueErr.err
+	}
+	value, final, err := l.retry.call(c, func() (interface{}, error) {
+		return l.call(ctx, f)
 	})
-	valueErr := l.value.Load().(*valueErr)
-	return valueErr.value, valueErr.err
+	if err == nil {
+		l.lifecycle.track(l.name, value)
+	}
+	if final && (err == nil || c.Err() == nil) {
+		l.value.Store(&valueErr{value, err})
+	}
+	return value, err
+}
+
+// call calls f within the timeout of the loader, if any. If a previous call
+// timed out and has not completed yet, it waits for that call instead of
+// calling f again, so that there is never more than one call at a time.
+func (l *loader) call(ctx ctx, f func(ctx ctx) (interface{}, error)) (interface{}, error) {
+	if l.timeout <= 0 {
+		return f(ctx)
+	}
+	construction := l.abandoned
+	if construction == nil {
+		construction = startConstruction(ctx, l.timeout, f)
+	}
+	if err := construction.await(ctx, l.timeout); err != nil {
+		if l.abandoned == nil {
+			l.abandoned = construction
+			go l.adopt(construction)
+		}
+		return nil, err
+	}
+	l.abandoned = nil
+	return construction.value, construction.err
+}
+
+// adopt waits for the given call of the constructor that was abandoned after a
+// timeout. Unless a later call of load has received its result already, the
+// value becomes the singleton if none has been loaded in the meantime, and is
+// discarded otherwise.
+func (l *loader) adopt(construction *construction) {
+	<-construction.done
+	l.sem <- struct{}{}
+	defer func() { <-l.sem }()
+
+	if l.abandoned != construction {
+		return
+	}
+	l.abandoned = nil
+	if construction.err == nil && l.value.Load() == nil {
+		l.lifecycle.track(l.name, construction.value)
+		l.value.Store(&construction.valueErr)
+		return
+	}
+	discard(l.name, construction.valueErr)
 }
 
 type valueErr struct {
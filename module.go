@@ -10,36 +10,36 @@ type module struct {
 	bindings      map[bindingKey]binding
 	bindingErrors []error
 	eager         []*singletonBuilder
+	// whether constructors of this module must not return nil values
+	rejectNilResults bool
+	// whether no constructor of the injector this module is installed in may
+	// return nil values, see RejectNilResults
+	injectorRejectsNil bool
 }
 
 func newModule() *module {
 	return &module{bindings: make(map[bindingKey]binding), bindingErrors: make([]error, 0)}
 }
 
-func (m *module) BindConstructor(fn interface{}) {
-	m.bindAuto(false, fn)
+func (m *module) BindConstructor(fn interface{}) ConstructorBuilder {
+	return m.bindAuto(fn).ToConstructor(fn)
 }
 
 func (m *module) BindSingletonConstructor(fn interface{}) SingletonBuilder {
-	return m.bindAuto(true, fn)
+	return m.bindAuto(fn).ToSingletonConstructor(fn)
 }
 
-func (m *module) bindAuto(singleton bool, fn interface{}) SingletonBuilder {
+func (m *module) bindAuto(fn interface{}) Builder {
 	t := reflect.TypeOf(fn)
 	if err := verifyConstructorReflectType(nil, t); err != nil {
 		m.addBindingError(err)
-		return nil
+		return newNoOpBuilder()
 	}
 	out := t.Out(0)
 	if out.Kind() == reflect.Interface {
 		out = reflect.PtrTo(out)
 	}
-	if singleton {
-		m.Bind(out).ToSingletonConstructor(fn)
-		return newSingletonBuilder(m, out)
-	}
-	m.Bind(out).ToConstructor(fn)
-	return nil
+	return m.Bind(out)
 }
 
 func (m *module) BindSingleton(singleton interface{}) {
@@ -148,6 +148,74 @@ func (m *module) BindTaggedString(tag string) Builder {
 	return m.bindTaggedConstant(tag, stringConstantKind)
 }
 
+func (m *module) BindMulti(sliceType interface{}) MultiBuilder {
+	return m.bindMulti(newBindingKey, sliceType)
+}
+
+func (m *module) BindTaggedMulti(tag string, sliceType interface{}) MultiBuilder {
+	if !m.verifyTag(tag) {
+		return (*multiBuilder)(nil)
+	}
+	return m.bindMulti(func(fromReflectType reflect.Type) bindingKey { return newTaggedBindingKey(fromReflectType, tag) }, sliceType)
+}
+
+func (m *module) bindMulti(newBindingKeyFunc func(reflect.Type) bindingKey, sliceType interface{}) MultiBuilder {
+	multiBinding, _ := m.multiBinding(newBindingKeyFunc, sliceType, reflect.Slice)
+	if multiBinding == nil {
+		return (*multiBuilder)(nil)
+	}
+	return &multiBuilder{m, multiBinding}
+}
+
+func (m *module) BindMap(mapType interface{}) MapBuilder {
+	return m.bindMap(newBindingKey, mapType)
+}
+
+func (m *module) BindTaggedMap(tag string, mapType interface{}) MapBuilder {
+	if !m.verifyTag(tag) {
+		return (*mapBuilder)(nil)
+	}
+	return m.bindMap(func(fromReflectType reflect.Type) bindingKey { return newTaggedBindingKey(fromReflectType, tag) }, mapType)
+}
+
+func (m *module) bindMap(newBindingKeyFunc func(reflect.Type) bindingKey, mapType interface{}) MapBuilder {
+	multiBinding, mapReflectType := m.multiBinding(newBindingKeyFunc, mapType, reflect.Map)
+	if multiBinding == nil {
+		return (*mapBuilder)(nil)
+	}
+	return &mapBuilder{m, multiBinding, mapReflectType.Key()}
+}
+
+// multiBinding returns the multibinding of this module for the given slice or
+// map type, creating it if necessary, along with the reflect type. Returns nil
+// if the type is not of the given kind or has a regular binding.
+func (m *module) multiBinding(newBindingKeyFunc func(reflect.Type) bindingKey, from interface{}, kind reflect.Kind) (*multiBinding, reflect.Type) {
+	fromReflectType, ok := from.(reflect.Type)
+	if !ok {
+		fromReflectType = reflect.TypeOf(from)
+	}
+	if fromReflectType == nil {
+		m.addBindingError(errNil)
+		return nil, nil
+	}
+	if fromReflectType.Kind() != kind {
+		m.addNotSupportedBindTypeError(fromReflectType)
+		return nil, nil
+	}
+	bindingKey := newBindingKeyFunc(fromReflectType)
+	foundBinding, ok := m.bindings[bindingKey]
+	if !ok {
+		foundBinding = newMultiBinding(fromReflectType.Elem())
+		m.bindings[bindingKey] = foundBinding
+	}
+	multiBinding, ok := foundBinding.(*multiBinding)
+	if !ok {
+		m.addBindingError(errAlreadyBound.withTag("bindingKey", bindingKey).withTag("foundBinding", foundBinding))
+		return nil, nil
+	}
+	return multiBinding, fromReflectType
+}
+
 func (m *module) bindTaggedConstant(tag string, constantKind constantKind) Builder {
 	if !m.verifyTag(tag) {
 		return newNoOpBuilder()
@@ -191,13 +259,46 @@ func (m *module) Install(others ...Module) {
 func (m *module) install(o *module) {
 	m.bindingErrors = append(m.bindingErrors, o.bindingErrors...)
 	m.eager = append(m.eager, o.eager...)
+	m.injectorRejectsNil = m.injectorRejectsNil || o.injectorRejectsNil
 	for key, value := range o.bindings {
-		m.setBinding(key, value)
+		m.setBinding(key, o.exportedBinding(value))
+	}
+}
+
+// exportedBinding returns the given binding of this module for installation
+// in another module. The binding keeps rejecting nil results if this module
+// rejects them. Multibindings are copied, so that elements added later to
+// either module do not affect the other.
+func (m *module) exportedBinding(binding binding) binding {
+	if m.rejectNilResults {
+		if r, ok := binding.(nilRejecter); ok {
+			return r.rejectingNil()
+		}
 	}
+	if multiBinding, ok := binding.(*multiBinding); ok {
+		return multiBinding.copy()
+	}
+	return binding
 }
 
 func (m *module) CallEagerly(function interface{}) {
-	newSingletonBuilder(m, nil).EagerlyAndCall(function)
+	newSingletonBuilder(m, nil, nil).EagerlyAndCall(function)
+}
+
+func (m *module) RejectNilResults() {
+	m.rejectNilResults = true
+}
+
+// RejectNilResults returns a module that makes the injector it is installed
+// in, and its child injectors, reject nil results of all constructors, as if
+// every module of the injector called Module.RejectNilResults. Use AllowNil on
+// the builder of a binding to exempt it:
+//
+//	injector, err := inject.NewInjector(inject.RejectNilResults(), appModule, libModule)
+func RejectNilResults() Module {
+	m := newModule()
+	m.injectorRejectsNil = true
+	return m
 }
 
 func (m *module) keyValueStrings() []string {
@@ -222,6 +323,18 @@ func (m *module) binding(bindingKey bindingKey) (binding, bool) {
 func (m *module) setBinding(bindingKey bindingKey, binding binding) {
 	foundBinding, ok := m.bindings[bindingKey]
 	if ok {
+		// the elements of multibindings of installed modules are merged
+		found, foundMulti := foundBinding.(*multiBinding)
+		other, otherMulti := binding.(*multiBinding)
+		if foundMulti && otherMulti {
+			m.bindings[bindingKey] = found.merge(other, nil)
+			return
+		}
+		// default bindings yield to regular bindings
+		if replaced, ok := replaceDefault(foundBinding, binding); ok {
+			m.bindings[bindingKey] = replaced
+			return
+		}
 		m.addBindingError(errAlreadyBound.withTag("bindingKey", bindingKey).withTag("foundBinding", foundBinding))
 		return
 	}
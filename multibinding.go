@@ -0,0 +1,368 @@
+package inject
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// multiBinding is the binding of a slice or map type to the elements
+// contributed by one or more modules, see Module.BindMulti and Module.BindMap.
+type multiBinding struct {
+	elementType      reflect.Type
+	elements         []*multiElement
+	permitDuplicates bool
+	// the modules that install the elements into an injector, one per
+	// element. Only set for the merged multibindings of an injector.
+	modules []*module
+}
+
+// multiElement is an element contributed to a multibinding, or an entry
+// contributed to a map binding.
+type multiElement struct {
+	binding  binding
+	priority int
+	// the key of a map entry
+	key interface{}
+	// the code location that contributed the map entry
+	source string
+	// the element this element is a copy of, identifying the contribution
+	origin *multiElement
+	// the value of a singleton element that is identified by its value (see
+	// isIdentifiable), nil otherwise
+	value interface{}
+}
+
+func newMultiBinding(elementType reflect.Type) *multiBinding {
+	return &multiBinding{elementType: elementType}
+}
+
+func (m *multiBinding) String() string {
+	elements := make([]string, len(m.elements))
+	for i, e := range m.elements {
+		if e.source != "" {
+			elements[i] = fmt.Sprintf("%v: %s", e.key, e.binding)
+		} else {
+			elements[i] = e.binding.String()
+		}
+	}
+	return fmt.Sprintf("multibinding [%s]", strings.Join(elements, ", "))
+}
+
+// add adds an element with the given binding to this multibinding.
+func (m *multiBinding) add(binding binding, value interface{}) *multiElement {
+	e := &multiElement{binding: binding}
+	e.origin = e
+	if value != nil && isIdentifiable(reflect.TypeOf(value)) {
+		e.value = value
+	}
+	m.elements = append(m.elements, e)
+	return e
+}
+
+// merge returns a new multibinding with the elements of this multibinding,
+// followed by the elements of the other one installed from the given module.
+func (m *multiBinding) merge(other *multiBinding, installer *module) *multiBinding {
+	merged := *m
+	merged.elements = append(append([]*multiElement(nil), m.elements...), other.elements...)
+	merged.permitDuplicates = m.permitDuplicates || other.permitDuplicates
+	if installer != nil {
+		merged.modules = append([]*module(nil), m.modules...)
+		for range other.elements {
+			merged.modules = append(merged.modules, installer)
+		}
+	}
+	return &merged
+}
+
+// copy returns a copy of this multibinding, so that elements added to either
+// of them do not affect the other.
+func (m *multiBinding) copy() *multiBinding {
+	return newMultiBinding(m.elementType).merge(m, nil)
+}
+
+func (m *multiBinding) rejectingNil() binding {
+	res := m.copy()
+	for i, e := range res.elements {
+		if r, ok := e.binding.(nilRejecter); ok {
+			rejecting := *e
+			rejecting.binding = r.rejectingNil()
+			res.elements[i] = &rejecting
+		}
+	}
+	return res
+}
+
+func (m *multiBinding) resolvedBinding(module *module, injector *injector, key bindingKey) (resolvedBinding, error) {
+	resolved := &resolvedMultiBinding{reflectType: key.reflectType()}
+	isMap := resolved.reflectType.Kind() == reflect.Map
+	entries := make(map[interface{}]*multiElement)
+	seen := make(map[interface{}]bool)
+	for i, e := range m.elements {
+		if isMap {
+			if found, ok := entries[e.key]; ok {
+				return nil, errDuplicateMapKey.
+					withTag("bindingKey", key).
+					withTag("key", e.key).
+					withTag("source", e.source).
+					withTag("foundSource", found.source)
+			}
+			entries[e.key] = e
+		}
+		duplicate := seen[e.origin] || e.value != nil && seen[e.value]
+		if duplicate && !m.permitDuplicates {
+			return nil, errDuplicateElement.withTag("bindingKey", key).withTag("element", e.binding)
+		}
+		seen[e.origin] = true
+		if e.value != nil {
+			seen[e.value] = true
+		}
+		if duplicate {
+			continue
+		}
+
+		elementModule := module
+		if m.modules != nil {
+			elementModule = m.modules[i]
+		}
+		binding, err := e.binding.resolvedBinding(elementModule, injector, key)
+		if err != nil {
+			return nil, err
+		}
+		resolved.elements = append(resolved.elements, binding)
+		resolved.priorities = append(resolved.priorities, e.priority)
+		resolved.keys = append(resolved.keys, e.key)
+	}
+	sort.Stable(resolved)
+	return resolved, nil
+}
+
+// multibindings collects the multibindings of the modules of an injector,
+// merging the elements contributed by different modules.
+type multibindings map[bindingKey]*multiBinding
+
+// add adds the given binding installed from the given module and returns true
+// if it is a multibinding, or returns false otherwise.
+func (m multibindings) add(key bindingKey, binding binding, module *module) bool {
+	multiBinding, ok := binding.(*multiBinding)
+	if !ok {
+		return false
+	}
+	found, ok := m[key]
+	if !ok {
+		found = newMultiBinding(multiBinding.elementType)
+	}
+	m[key] = found.merge(multiBinding, module)
+	return true
+}
+
+// resolvedMultiBinding provides the slice of all elements of a multibinding,
+// or the map of all entries of a map binding.
+type resolvedMultiBinding struct {
+	reflectType reflect.Type
+	elements    []resolvedBinding
+	priorities  []int
+	// the keys of the map entries
+	keys []interface{}
+}
+
+func (r *resolvedMultiBinding) String() string {
+	elements := make([]string, len(r.elements))
+	for i, e := range r.elements {
+		if r.reflectType.Kind() == reflect.Map {
+			elements[i] = fmt.Sprintf("%v: %s", r.keys[i], e)
+		} else {
+			elements[i] = e.String()
+		}
+	}
+	return fmt.Sprintf("multibinding [%s]", strings.Join(elements, ", "))
+}
+
+func (r *resolvedMultiBinding) validate(ctx ctx) error {
+	for _, e := range r.elements {
+		if err := e.validate(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *resolvedMultiBinding) get(ctx ctx) (interface{}, error) {
+	var result reflect.Value
+	if r.reflectType.Kind() == reflect.Map {
+		result = reflect.MakeMapWithSize(r.reflectType, len(r.elements))
+	} else {
+		result = reflect.MakeSlice(r.reflectType, 0, len(r.elements))
+	}
+	for i, e := range r.elements {
+		value, err := e.get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		reflectValue := reflect.Zero(r.reflectType.Elem())
+		if value != nil {
+			reflectValue = reflect.ValueOf(value)
+		}
+		if r.reflectType.Kind() == reflect.Map {
+			result.SetMapIndex(reflect.ValueOf(r.keys[i]), reflectValue)
+		} else {
+			result = reflect.Append(result, reflectValue)
+		}
+	}
+	return result.Interface(), nil
+}
+
+// Len, Less and Swap sort the elements by descending priority.
+
+func (r *resolvedMultiBinding) Len() int {
+	return len(r.elements)
+}
+
+func (r *resolvedMultiBinding) Less(i, j int) bool {
+	return r.priorities[i] > r.priorities[j]
+}
+
+func (r *resolvedMultiBinding) Swap(i, j int) {
+	r.elements[i], r.elements[j] = r.elements[j], r.elements[i]
+	r.priorities[i], r.priorities[j] = r.priorities[j], r.priorities[i]
+	r.keys[i], r.keys[j] = r.keys[j], r.keys[i]
+}
+
+type multiBuilder struct {
+	module       *module
+	multiBinding *multiBinding
+}
+
+func (b *multiBuilder) ToSingleton(element interface{}) ElementBuilder {
+	return b.add(element, verifyElementReflectType, newSingletonBinding, element)
+}
+
+func (b *multiBuilder) ToConstructor(constructor interface{}) ElementBuilder {
+	return b.add(constructor, verifyElementConstructorReflectType, newConstructorBinding, nil)
+}
+
+func (b *multiBuilder) ToSingletonConstructor(constructor interface{}) ElementBuilder {
+	return b.add(constructor, verifyElementConstructorReflectType, newSingletonConstructorBinding, nil)
+}
+
+func (b *multiBuilder) ToTaggedConstructor(constructor interface{}) ElementBuilder {
+	return b.add(constructor, verifyElementTaggedConstructorReflectType, newTaggedConstructorBinding, nil)
+}
+
+func (b *multiBuilder) PermitDuplicates() MultiBuilder {
+	if b == nil {
+		return b
+	}
+	b.multiBinding.permitDuplicates = true
+	return b
+}
+
+// add adds an element for the given object to the multibinding. The given
+// value of singleton elements identifies duplicates.
+func (b *multiBuilder) add(object interface{}, verifyFunc func(reflect.Type, reflect.Type) error, newBindingFunc func(interface{}) binding, value interface{}) ElementBuilder {
+	if b == nil {
+		return (*elementBuilder)(nil)
+	}
+	if object == nil {
+		b.module.addBindingError(errNil)
+		return (*elementBuilder)(nil)
+	}
+	if err := verifyFunc(b.multiBinding.elementType, reflect.TypeOf(object)); err != nil {
+		b.module.addBindingError(err)
+		return (*elementBuilder)(nil)
+	}
+	return &elementBuilder{b.multiBinding.add(newBindingFunc(object), value)}
+}
+
+type mapBuilder struct {
+	module       *module
+	multiBinding *multiBinding
+	keyType      reflect.Type
+}
+
+func (b *mapBuilder) ToSingleton(key interface{}, value interface{}) {
+	b.add(key, value, verifyElementReflectType, newSingletonBinding)
+}
+
+func (b *mapBuilder) ToConstructor(key interface{}, constructor interface{}) {
+	b.add(key, constructor, verifyElementConstructorReflectType, newConstructorBinding)
+}
+
+func (b *mapBuilder) ToSingletonConstructor(key interface{}, constructor interface{}) {
+	b.add(key, constructor, verifyElementConstructorReflectType, newSingletonConstructorBinding)
+}
+
+func (b *mapBuilder) ToTaggedConstructor(key interface{}, constructor interface{}) {
+	b.add(key, constructor, verifyElementTaggedConstructorReflectType, newTaggedConstructorBinding)
+}
+
+// add adds an entry with the given key for the given object to the map
+// binding, recording the code location of the caller of the builder as the
+// source of the entry.
+func (b *mapBuilder) add(key interface{}, object interface{}, verifyFunc func(reflect.Type, reflect.Type) error, newBindingFunc func(interface{}) binding) {
+	if b == nil {
+		return
+	}
+	if key == nil || object == nil {
+		b.module.addBindingError(errNil)
+		return
+	}
+	if err := verifyElementReflectType(b.keyType, reflect.TypeOf(key)); err != nil {
+		b.module.addBindingError(err)
+		return
+	}
+	if err := verifyFunc(b.multiBinding.elementType, reflect.TypeOf(object)); err != nil {
+		b.module.addBindingError(err)
+		return
+	}
+	e := b.multiBinding.add(newBindingFunc(object), nil)
+	e.key = reflect.ValueOf(key).Convert(b.keyType).Interface()
+	e.source = callerName(4, "unknown")
+}
+
+type elementBuilder struct {
+	element *multiElement
+}
+
+func (b *elementBuilder) WithPriority(priority int) ElementBuilder {
+	if b == nil {
+		return b
+	}
+	b.element.priority = priority
+	return b
+}
+
+// isIdentifiable returns true if values of the given type are identified by
+// their value, i.e. two equal values are considered the same element.
+func isIdentifiable(reflectType reflect.Type) bool {
+	switch reflectType.Kind() {
+	case reflect.Ptr, reflect.Chan, reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	}
+	return false
+}
+
+func verifyElementReflectType(elementReflectType reflect.Type, reflectType reflect.Type) error {
+	if !reflectType.AssignableTo(elementReflectType) {
+		return errNotAssignable.withTag("elementReflectType", elementReflectType).withTag("bindingReflectType", reflectType)
+	}
+	return nil
+}
+
+func verifyElementConstructorReflectType(elementReflectType reflect.Type, constructorReflectType reflect.Type) error {
+	if err := verifyConstructorReflectType(nil, constructorReflectType); err != nil {
+		return err
+	}
+	return verifyElementReflectType(elementReflectType, constructorReflectType.Out(0))
+}
+
+func verifyElementTaggedConstructorReflectType(elementReflectType reflect.Type, constructorReflectType reflect.Type) error {
+	if err := verifyTaggedConstructorReflectType(nil, constructorReflectType); err != nil {
+		return err
+	}
+	return verifyElementReflectType(elementReflectType, constructorReflectType.Out(0))
+}
@@ -0,0 +1,273 @@
+package inject
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type route interface {
+	path() string
+}
+
+type staticRoute string
+
+func (r staticRoute) path() string {
+	return string(r)
+}
+
+type routeConfig struct {
+	prefix string
+}
+
+func newHealthRoute(config *routeConfig) route {
+	return staticRoute(config.prefix + "/health")
+}
+
+// routePaths returns the paths of the routes of the multibinding with the
+// given tag, if any.
+func routePaths(t *testing.T, injector Injector, tag string) []string {
+	var value interface{}
+	var err error
+	if tag == "" {
+		value, err = injector.Get([]route(nil))
+	} else {
+		value, err = injector.GetTagged(tag, []route(nil))
+	}
+	require.NoError(t, err)
+	var paths []string
+	for _, r := range value.([]route) {
+		paths = append(paths, r.path())
+	}
+	return paths
+}
+
+func TestMultibinding(t *testing.T) {
+	core := NewModule()
+	core.BindSingleton(&routeConfig{"/api"})
+	core.BindMulti([]route(nil)).ToSingleton(staticRoute("/users"))
+	core.BindMulti([]route(nil)).ToConstructor(newHealthRoute)
+
+	plugin := NewModule()
+	plugin.BindMulti([]route(nil)).ToTaggedConstructor(func(s struct {
+		Config *routeConfig
+		Name   string `inject:"plugin"`
+	}) route {
+		return staticRoute(s.Config.prefix + "/" + s.Name)
+	})
+	plugin.BindMulti([]route(nil)).ToSingleton(staticRoute("/first")).WithPriority(10)
+	plugin.BindTaggedString("plugin").ToSingleton("metrics")
+
+	injector, err := NewInjector(core, plugin)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/first", "/users", "/api/health", "/api/metrics"}, routePaths(t, injector, ""))
+
+	_, err = injector.Call(func(routes []route) {
+		require.Len(t, routes, 4)
+	})
+	require.NoError(t, err)
+
+	tree, err := injector.DependencyTree()
+	require.NoError(t, err)
+	require.Contains(t, tree.String(), "{type:[]inject.route} : multibinding [singleton inject.staticRoute, ")
+	require.Contains(t, tree.String(), "{type:*inject.routeConfig} : singleton *inject.routeConfig")
+}
+
+func TestMultibindingInstall(t *testing.T) {
+	shared := NewModule()
+	shared.BindMulti([]route(nil)).ToSingleton(staticRoute("/shared"))
+
+	a := NewModule()
+	a.Install(shared)
+	a.BindMulti([]route(nil)).ToSingleton(staticRoute("/a"))
+	b := NewModule()
+	b.BindMulti([]route(nil)).ToSingleton(staticRoute("/b"))
+	b.Install(shared)
+
+	t.Run("duplicates rejected", func(t *testing.T) {
+		_, err := NewInjector(a, b)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), injectErrorTypeDuplicateElement)
+		require.Contains(t, err.Error(), "bindingKey:{type:[]inject.route}")
+	})
+
+	t.Run("duplicates permitted", func(t *testing.T) {
+		m := NewModule()
+		m.BindMulti([]route(nil)).PermitDuplicates()
+		injector, err := NewInjector(a, b, m)
+		require.NoError(t, err)
+		require.Equal(t, []string{"/shared", "/a", "/b"}, routePaths(t, injector, ""))
+	})
+
+	t.Run("installed module unchanged", func(t *testing.T) {
+		injector, err := NewInjector(shared)
+		require.NoError(t, err)
+		require.Equal(t, []string{"/shared"}, routePaths(t, injector, ""))
+	})
+}
+
+func TestTaggedMultibinding(t *testing.T) {
+	m := NewModule()
+	m.BindTaggedMulti("admin", []route(nil)).ToSingleton(staticRoute("/admin"))
+	m.BindMulti([]route(nil))
+	injector, err := NewInjector(m)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"/admin"}, routePaths(t, injector, "admin"))
+	require.Empty(t, routePaths(t, injector, ""))
+}
+
+func TestMultibindingSingletonConstructor(t *testing.T) {
+	created := 0
+	m := NewModule()
+	m.BindMulti([]route(nil)).ToSingletonConstructor(func() route {
+		created++
+		return staticRoute("/once")
+	})
+	injector, err := NewInjector(m)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		require.Equal(t, []string{"/once"}, routePaths(t, injector, ""))
+	}
+	require.Equal(t, 1, created)
+}
+
+func TestMultibindingErrors(t *testing.T) {
+	t.Run("not a slice", func(t *testing.T) {
+		m := NewModule()
+		m.BindMulti(staticRoute("")).ToSingleton(staticRoute("/a"))
+		_, err := NewInjector(m)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), injectErrorTypeNotSupportedBindType)
+	})
+
+	t.Run("not assignable", func(t *testing.T) {
+		m := NewModule()
+		m.BindMulti([]route(nil)).ToSingleton("/a")
+		m.BindMulti([]route(nil)).ToConstructor(func() string { return "/b" })
+		_, err := NewInjector(m)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), injectErrorTypeNotAssignable)
+	})
+
+	t.Run("regular binding", func(t *testing.T) {
+		m := NewModule()
+		m.Bind([]route(nil)).ToSingleton([]route{staticRoute("/a")})
+		m.BindMulti([]route(nil)).ToSingleton(staticRoute("/b"))
+		_, err := NewInjector(m)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), injectErrorTypeAlreadyBound)
+	})
+
+	t.Run("missing dependency", func(t *testing.T) {
+		m := NewModule()
+		m.BindMulti([]route(nil)).ToConstructor(newHealthRoute)
+		_, err := NewInjector(m)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), injectErrorTypeNoBinding)
+		require.Contains(t, err.Error(), "bindingKey:{type:*inject.routeConfig}")
+	})
+
+	t.Run("multibinding of parent", func(t *testing.T) {
+		m := NewModule()
+		m.BindMulti([]route(nil)).ToSingleton(staticRoute("/a"))
+		injector, err := NewInjector(m)
+		require.NoError(t, err)
+		cm := NewModule()
+		cm.BindMulti([]route(nil)).ToSingleton(staticRoute("/b"))
+		_, err = injector.NewChildInjector(nil, cm)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), injectErrorTypeAlreadyBound)
+		require.Contains(t, err.Error(), "scope:parent")
+	})
+}
+
+func TestMapBindingSortKeepsKeys(t *testing.T) {
+	r := &resolvedMultiBinding{
+		elements:   make([]resolvedBinding, 3),
+		priorities: []int{0, 5, 10},
+		keys:       []interface{}{"low", "medium", "high"},
+	}
+	sort.Stable(r)
+	require.Equal(t, []int{10, 5, 0}, r.priorities)
+	require.Equal(t, []interface{}{"high", "medium", "low"}, r.keys)
+}
+
+type routeFactory func(prefix string) route
+
+func TestMapBinding(t *testing.T) {
+	users := NewModule()
+	users.BindSingleton(&routeConfig{"/api"})
+	users.BindMap(map[string]route(nil)).ToSingleton("users", staticRoute("/users"))
+	users.BindMap(map[string]routeFactory(nil)).ToSingleton("users", func(prefix string) route {
+		return staticRoute(prefix + "/users")
+	})
+
+	health := NewModule()
+	health.BindMap(map[string]route(nil)).ToConstructor("health", newHealthRoute)
+	health.BindMap(map[string]route(nil)).ToTaggedConstructor("status", func(s struct {
+		Config *routeConfig
+	}) route {
+		return staticRoute(s.Config.prefix + "/status")
+	})
+	health.BindTaggedMap("admin", map[string]route(nil)).ToSingletonConstructor("admin", func() route {
+		return staticRoute("/admin")
+	})
+
+	injector, err := NewInjector(users, health)
+	require.NoError(t, err)
+
+	routes, err := injector.Get(map[string]route(nil))
+	require.NoError(t, err)
+	require.Equal(t, map[string]route{
+		"users":  staticRoute("/users"),
+		"health": staticRoute("/api/health"),
+		"status": staticRoute("/api/status"),
+	}, routes)
+
+	admin, err := injector.GetTagged("admin", map[string]route(nil))
+	require.NoError(t, err)
+	require.Equal(t, map[string]route{"admin": staticRoute("/admin")}, admin)
+
+	factories, err := injector.Get(map[string]routeFactory(nil))
+	require.NoError(t, err)
+	require.Equal(t, staticRoute("/v1/users"), factories.(map[string]routeFactory)["users"]("/v1"))
+
+	tree, err := injector.DependencyTree()
+	require.NoError(t, err)
+	require.Contains(t, tree.String(), "{type:map[string]inject.route tag:admin} : multibinding [admin: singleton <")
+}
+
+func TestMapBindingErrors(t *testing.T) {
+	t.Run("duplicate key", func(t *testing.T) {
+		a := NewModule()
+		a.BindMap(map[string]route(nil)).ToSingleton("users", staticRoute("/users"))
+		b := NewModule()
+		b.BindMap(map[string]route(nil)).ToSingleton("users", staticRoute("/accounts"))
+		_, err := NewInjector(a, b)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), injectErrorTypeDuplicateMapKey)
+		require.Contains(t, err.Error(), "key:users")
+		require.Contains(t, err.Error(), "source:/")
+		require.Contains(t, err.Error(), "multibinding_test.go:")
+		require.Contains(t, err.Error(), "foundSource:/")
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		m := NewModule()
+		m.BindMap(map[string]route(nil)).ToSingleton(1, staticRoute("/users"))
+		_, err := NewInjector(m)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), injectErrorTypeNotAssignable)
+	})
+
+	t.Run("not a map", func(t *testing.T) {
+		m := NewModule()
+		m.BindMap([]route(nil)).ToSingleton("users", staticRoute("/users"))
+		_, err := NewInjector(m)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), injectErrorTypeNotSupportedBindType)
+	})
+}
@@ -0,0 +1,119 @@
+package inject
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type nilService interface {
+	Serve() string
+}
+
+type nilDB struct{}
+
+type nilCache struct{}
+
+func newNilService() nilService { return nil }
+
+func newNilDB() *nilDB { return nil }
+
+func newNilCache() (*nilCache, error) { return nil, nil }
+
+func TestNilResultsAllowedByDefault(t *testing.T) {
+	module := NewModule()
+	module.BindConstructor(newNilService)
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	value, err := injector.Get((*nilService)(nil))
+	require.NoError(t, err)
+	require.Nil(t, value)
+}
+
+func TestRejectNilResults(t *testing.T) {
+	module := NewModule()
+	module.RejectNilResults()
+	module.BindConstructor(newNilService)
+	module.BindSingletonConstructor(newNilDB)
+	module.BindTagged("cache", &nilCache{}).ToSingletonConstructor(newNilCache).AllowNil()
+	module.BindTaggedString("name").ToConstructor(func() string { return "" })
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	_, err = injector.Get((*nilService)(nil))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeNilResult)
+	require.Contains(t, err.Error(), "constructor:<github.com/eluv-io/inject-go.newNilService()")
+	require.Contains(t, err.Error(), "bindingKey:{type:*inject.nilService}")
+
+	_, err = injector.Get(&nilDB{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeNilResult)
+
+	value, err := injector.GetTagged("cache", &nilCache{})
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	name, err := injector.GetTaggedString("name")
+	require.NoError(t, err)
+	require.Equal(t, "", name)
+}
+
+func TestRejectNilResultsInstalled(t *testing.T) {
+	rejecting := NewModule()
+	rejecting.RejectNilResults()
+	rejecting.BindConstructor(newNilService)
+	allowing := NewModule()
+	allowing.BindSingletonConstructor(newNilDB)
+
+	module := NewModule()
+	module.Install(rejecting, allowing)
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	_, err = injector.Get((*nilService)(nil))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeNilResult)
+	_, err = injector.Get(&nilDB{})
+	require.NoError(t, err)
+
+	// rejecting nil results in the combined module applies to all bindings
+	combined := Combine(allowing)
+	combined.RejectNilResults()
+	injector, err = NewInjector(combined)
+	require.NoError(t, err)
+	_, err = injector.Get(&nilDB{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeNilResult)
+}
+
+func TestRejectNilResultsInjector(t *testing.T) {
+	module := NewModule()
+	module.BindConstructor(newNilService)
+	module.BindTagged("cache", &nilCache{}).ToSingletonConstructor(newNilCache).AllowNil()
+	injector, err := NewInjector(module, RejectNilResults())
+	require.NoError(t, err)
+
+	_, err = injector.Get((*nilService)(nil))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeNilResult)
+	value, err := injector.GetTagged("cache", &nilCache{})
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	// child injectors reject nil results as well
+	childModule := NewModule()
+	childModule.BindSingletonConstructor(newNilDB)
+	child, err := injector.NewChildInjector(nil, childModule)
+	require.NoError(t, err)
+	_, err = child.Get(&nilDB{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeNilResult)
+
+	// other injectors are not affected
+	injector, err = NewInjector(module)
+	require.NoError(t, err)
+	_, err = injector.Get((*nilService)(nil))
+	require.NoError(t, err)
+}
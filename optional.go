@@ -0,0 +1,198 @@
+package inject
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Optional is a dependency of type T that does not need to be bound. A
+// constructor or function parameter or a struct field of type Optional[T] is
+// injected with the value bound to T, or with an empty Optional if there is no
+// binding for T:
+//
+//	func newServer(tracer inject.Optional[Tracer]) *Server {
+//		if t, ok := tracer.Get(); ok {
+//			...
+//		}
+//	}
+type Optional[T any] struct {
+	value   T
+	present bool
+}
+
+// OptionalOf returns an Optional with the given value, e.g. to call a
+// constructor with an optional parameter in a test.
+func OptionalOf[T any](value T) Optional[T] {
+	return Optional[T]{value, true}
+}
+
+// Get returns the value and true if the dependency is bound, or the zero
+// value and false otherwise.
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.present
+}
+
+// Present returns true if the dependency is bound.
+func (o Optional[T]) Present() bool {
+	return o.present
+}
+
+// OrElse returns the value if the dependency is bound, or the given value
+// otherwise.
+func (o Optional[T]) OrElse(value T) T {
+	if o.present {
+		return o.value
+	}
+	return value
+}
+
+func (o Optional[T]) elemReflectType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (o Optional[T]) of(value interface{}) interface{} {
+	v, _ := value.(T)
+	return Optional[T]{v, true}
+}
+
+// optional is implemented by all Optional types.
+type optional interface {
+	elemReflectType() reflect.Type
+	of(value interface{}) interface{}
+}
+
+var optionalReflectType = reflect.TypeOf((*optional)(nil)).Elem()
+
+// optionalElemReflectType returns T and true if the given type is Optional[T],
+// or the given type and false otherwise.
+func optionalElemReflectType(reflectType reflect.Type) (reflect.Type, bool) {
+	if reflectType.Kind() != reflect.Struct || !reflectType.Implements(optionalReflectType) {
+		return reflectType, false
+	}
+	return reflect.Zero(reflectType).Interface().(optional).elemReflectType(), true
+}
+
+// optionalBindingKey is the key of an optional dependency, i.e. a parameter or
+// field of type Optional[T] or a field tagged as optional or with a default
+// value. Optional dependencies are satisfied if their key is not bound.
+type optionalBindingKey struct {
+	bindingKey
+	// the type of the parameter or field
+	valueType reflect.Type
+	// the default value of a constant, if any
+	defaultValue interface{}
+}
+
+// absent returns the value of the dependency if its key is not bound.
+func (o optionalBindingKey) absent() reflect.Value {
+	if o.defaultValue != nil {
+		return o.present(o.defaultValue)
+	}
+	return reflect.Zero(o.valueType)
+}
+
+// present returns the value of the dependency for the given value bound to its
+// key.
+func (o optionalBindingKey) present(value interface{}) reflect.Value {
+	if _, ok := optionalElemReflectType(o.valueType); ok {
+		return reflect.ValueOf(reflect.Zero(o.valueType).Interface().(optional).of(value))
+	}
+	return reflect.ValueOf(value)
+}
+
+// injectTag is the parsed "inject" tag of a struct field, which consists of
+// the tag of the binding followed by options, e.g. `inject:"port,default=8080"`.
+type injectTag struct {
+	name     string
+	optional bool
+	// the default value, see parseDefaultValue
+	defaultValue string
+	hasDefault   bool
+}
+
+func parseInjectTag(tag string) (injectTag, *injectError) {
+	parts := strings.Split(tag, ",")
+	res := injectTag{name: parts[0]}
+	for _, option := range parts[1:] {
+		switch {
+		case option == "optional":
+			res.optional = true
+		case strings.HasPrefix(option, "default="):
+			res.defaultValue = strings.TrimPrefix(option, "default=")
+			res.hasDefault = true
+		default:
+			return res, errInvalidInjectTag.withTag("tag", tag).withTag("option", option)
+		}
+	}
+	return res, nil
+}
+
+// parseDefaultValue parses the given default value of a dependency of the
+// given type, which must be a constant kind.
+func parseDefaultValue(reflectType reflect.Type, value string) (interface{}, error) {
+	if _, ok := constantKindForReflectType(reflectType); !ok {
+		return nil, errInvalidInjectTag.withTag("reflectType", reflectType).withTag("default", value)
+	}
+	res := reflect.New(reflectType).Elem()
+	var err error
+	switch reflectType.Kind() {
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(value)
+		res.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		i, err = strconv.ParseInt(value, 10, reflectType.Bits())
+		res.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		u, err = strconv.ParseUint(value, 10, reflectType.Bits())
+		res.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(value, reflectType.Bits())
+		res.SetFloat(f)
+	case reflect.Complex64, reflect.Complex128:
+		var c complex128
+		c, err = strconv.ParseComplex(value, reflectType.Bits())
+		res.SetComplex(c)
+	case reflect.String:
+		res.SetString(value)
+	}
+	if err != nil {
+		return nil, errInvalidInjectTag.withTag("reflectType", reflectType).withTag("default", value).withTag("err", err)
+	}
+	return res.Interface(), nil
+}
+
+// newParameterBindingKey returns the binding key of a parameter or field of
+// the given type with the given inject tag.
+func newParameterBindingKey(valueType reflect.Type, tag injectTag) bindingKey {
+	keyReflectType, isOptional := parameterKeyReflectType(valueType)
+	var key bindingKey
+	if tag.name != "" {
+		key = newTaggedBindingKey(keyReflectType, tag.name)
+	} else {
+		key = newBindingKey(keyReflectType)
+	}
+	if !isOptional && !tag.optional && !tag.hasDefault {
+		return key
+	}
+	optionalKey := optionalBindingKey{bindingKey: key, valueType: valueType}
+	if tag.hasDefault {
+		// verified by verifyStructCanBePopulated
+		optionalKey.defaultValue, _ = parseDefaultValue(keyReflectType, tag.defaultValue)
+	}
+	return optionalKey
+}
+
+// parameterKeyReflectType returns the type of the binding key of a parameter or
+// field of the given type, and true if it is an Optional.
+func parameterKeyReflectType(valueType reflect.Type) (reflect.Type, bool) {
+	keyReflectType, isOptional := optionalElemReflectType(valueType)
+	if isInterface(keyReflectType) {
+		keyReflectType = reflect.PtrTo(keyReflectType)
+	}
+	return keyReflectType, isOptional
+}
@@ -0,0 +1,157 @@
+package inject
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type tracer interface {
+	trace(string) string
+}
+
+type prefixTracer string
+
+func (p prefixTracer) trace(s string) string {
+	return string(p) + s
+}
+
+type server struct {
+	tracer Optional[tracer]
+	name   string
+	port   int
+}
+
+func newServer(t Optional[tracer]) *server {
+	return &server{tracer: t}
+}
+
+func newTaggedServer(s struct {
+	Tracer tracer `inject:",optional"`
+	Name   string `inject:"name,optional"`
+	Port   int    `inject:"port,default=8080"`
+}) *server {
+	res := &server{name: s.Name, port: s.Port}
+	if s.Tracer != nil {
+		res.tracer = OptionalOf(s.Tracer)
+	}
+	return res
+}
+
+func TestOptional(t *testing.T) {
+	m := NewModule()
+	m.BindConstructor(newServer)
+	injector, err := NewInjector(m)
+	require.NoError(t, err)
+
+	s, err := injector.Get(&server{})
+	require.NoError(t, err)
+	require.False(t, s.(*server).tracer.Present())
+	require.Equal(t, "none", s.(*server).tracer.OrElse(prefixTracer("none")).trace(""))
+
+	cm := NewModule()
+	cm.Bind((*tracer)(nil)).ToSingleton(prefixTracer("> "))
+	child, err := injector.NewChildInjector(nil, cm)
+	require.NoError(t, err)
+	_, err = child.Call(func(t2 Optional[tracer]) {
+		tr, ok := t2.Get()
+		require.True(t, ok)
+		require.Equal(t, "> call", tr.trace("call"))
+	})
+	require.NoError(t, err)
+
+	tree, err := injector.DependencyTree()
+	require.NoError(t, err)
+	require.NotContains(t, tree.String(), "inject.tracer")
+}
+
+func TestOptionalTaggedConstructor(t *testing.T) {
+	m := NewModule()
+	m.Bind(&server{}).ToTaggedConstructor(newTaggedServer)
+	injector, err := NewInjector(m)
+	require.NoError(t, err)
+
+	s, err := injector.Get(&server{})
+	require.NoError(t, err)
+	require.False(t, s.(*server).tracer.Present())
+	require.Equal(t, "", s.(*server).name)
+	require.Equal(t, 8080, s.(*server).port)
+
+	bm := NewModule()
+	bm.Bind((*tracer)(nil)).ToSingleton(prefixTracer("> "))
+	bm.BindTaggedString("name").ToSingleton("api")
+	bm.BindTaggedInt("port").ToSingleton(9090)
+	injector, err = NewInjector(m, bm)
+	require.NoError(t, err)
+
+	s, err = injector.Get(&server{})
+	require.NoError(t, err)
+	require.True(t, s.(*server).tracer.Present())
+	require.Equal(t, "api", s.(*server).name)
+	require.Equal(t, 9090, s.(*server).port)
+
+	tree, err := injector.DependencyTree()
+	require.NoError(t, err)
+	require.Contains(t, tree.String(), "│   ├── {type:int tag:port} : singleton int")
+}
+
+func TestOptionalPopulate(t *testing.T) {
+	m := NewModule()
+	m.BindTaggedFloat64("ratio").ToSingleton(0.5)
+	injector, err := NewInjector(m)
+	require.NoError(t, err)
+
+	var config struct {
+		Ratio   float64          `inject:"ratio,default=1.5"`
+		Timeout Optional[int]    `inject:"timeout"`
+		Retries uint8            `inject:"retries,default=3"`
+		Verbose bool             `inject:"verbose,default=true"`
+		Tracer  Optional[tracer] `inject:"tracer"`
+	}
+	require.NoError(t, injector.Populate(&config))
+	require.Equal(t, 0.5, config.Ratio)
+	require.False(t, config.Timeout.Present())
+	require.Equal(t, uint8(3), config.Retries)
+	require.True(t, config.Verbose)
+	require.False(t, config.Tracer.Present())
+}
+
+func TestOptionalErrors(t *testing.T) {
+	for name, constructor := range map[string]interface{}{
+		"unknown option": func(s struct {
+			Name string `inject:"name,required"`
+		}) *server {
+			return nil
+		},
+		"default of non-constant": func(s struct {
+			Tracer tracer `inject:"tracer,default=none"`
+		}) *server {
+			return nil
+		},
+		"invalid default": func(s struct {
+			Port int `inject:"port,default=http"`
+		}) *server {
+			return nil
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := NewModule()
+			m.Bind(&server{}).ToTaggedConstructor(constructor)
+			_, err := NewInjector(m)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), injectErrorTypeInvalidInjectTag)
+		})
+	}
+
+	t.Run("missing dependency of bound optional", func(t *testing.T) {
+		m := NewModule()
+		m.BindConstructor(newServer)
+		m.BindConstructor(func(config *routeConfig) tracer {
+			return prefixTracer(config.prefix)
+		})
+		_, err := NewInjector(m)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), injectErrorTypeNoBinding)
+		require.Contains(t, err.Error(), "bindingKey:{type:*inject.routeConfig}")
+	})
+}
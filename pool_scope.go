@@ -0,0 +1,109 @@
+package inject
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Resetter is implemented by pooled instances that need to be reset when they
+// are returned to their pool. See PoolScope.
+type Resetter interface {
+	Reset()
+}
+
+// PoolScope is a scope for expensive instances that are not safe for
+// concurrent use, e.g. encoders, buffers or parsers. Each binding in the scope
+// has its own pool in each injector. Each request of the binding takes an
+// instance from the pool, which is created with the constructor of the binding
+// if the pool is empty. Release returns an instance to its pool:
+//
+//	pool := inject.NewPoolScope()
+//	module.BindConstructor(newEncoder).In(pool)
+//	...
+//	encoder, err := injector.Get(&Encoder{})
+//	defer pool.Release(encoder)
+//
+// The pools are backed by sync.Pool, so released instances may be dropped at
+// any time. Only pointers can be pooled, since instances are identified by
+// their address until they are released. The scope does not keep the
+// instances it hands out alive, but every instance should be released.
+type PoolScope struct {
+	mu sync.Mutex
+	// the pools of the instances that have been handed out and not been
+	// released yet
+	taken map[pooledInstance]*sync.Pool
+}
+
+// pooledInstance identifies an instance handed out by a PoolScope without
+// keeping it alive.
+type pooledInstance struct {
+	t    reflect.Type
+	addr uintptr
+}
+
+func newPooledInstance(value interface{}) (pooledInstance, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return pooledInstance{}, false
+	}
+	return pooledInstance{v.Type(), v.Pointer()}, true
+}
+
+// NewPoolScope creates a new PoolScope.
+func NewPoolScope() *PoolScope {
+	return &PoolScope{
+		taken: make(map[pooledInstance]*sync.Pool),
+	}
+}
+
+func (p *PoolScope) String() string {
+	return "pool"
+}
+
+func (p *PoolScope) Scope(key fmt.Stringer, unscoped Provider) Provider {
+	pool := &sync.Pool{}
+	return func(ctx context.Context) (interface{}, error) {
+		value := pool.Get()
+		if value == nil {
+			var err error
+			if value, err = unscoped(ctx); err != nil {
+				return nil, err
+			}
+		}
+		instance, ok := newPooledInstance(value)
+		if !ok {
+			return nil, errNotPoolable.withTag("bindingKey", key).withTag("type", reflect.TypeOf(value))
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.taken[instance] = pool
+		return value, nil
+	}
+}
+
+// Release resets the given instance if it implements Resetter and returns it
+// to its pool. An instance must not be used anymore after it has been
+// released.
+//
+// Release fails if the instance was not handed out by this scope, or if it has
+// been released already.
+func (p *PoolScope) Release(value interface{}) error {
+	instance, ok := newPooledInstance(value)
+	if !ok {
+		return errNotPooled.withTag("type", reflect.TypeOf(value))
+	}
+	p.mu.Lock()
+	pool, ok := p.taken[instance]
+	delete(p.taken, instance)
+	p.mu.Unlock()
+	if !ok {
+		return errNotPooled.withTag("type", instance.t)
+	}
+	if resetter, ok := value.(Resetter); ok {
+		resetter.Reset()
+	}
+	pool.Put(value)
+	return nil
+}
@@ -0,0 +1,135 @@
+package inject
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type pooledEncoder struct {
+	buf *bytes.Buffer
+}
+
+func (e *pooledEncoder) Reset() {
+	e.buf.Reset()
+}
+
+// requireReused requires that an instance released after being taken with
+// take is eventually taken again with takeAgain. sync.Pool does not guarantee
+// this, e.g. it drops released instances randomly when the race detector is
+// enabled, so it is tried multiple times.
+func requireReused(t *testing.T, pool *PoolScope, take, takeAgain func() (interface{}, error)) {
+	for i := 0; i < 100; i++ {
+		value, err := take()
+		require.NoError(t, err)
+		require.NoError(t, pool.Release(value))
+		again, err := takeAgain()
+		require.NoError(t, err)
+		require.NoError(t, pool.Release(again))
+		if value == again {
+			return
+		}
+	}
+	t.Fatal("released instance was never reused")
+}
+
+func TestPoolScope(t *testing.T) {
+	var created int
+	pool := NewPoolScope()
+	module := NewModule()
+	module.BindConstructor(func() *pooledEncoder {
+		created++
+		return &pooledEncoder{&bytes.Buffer{}}
+	}).In(pool)
+	module.BindConstructor(func() *bytes.Buffer {
+		return &bytes.Buffer{}
+	}).In(pool)
+	module.BindTagged("other", &bytes.Buffer{}).ToScopedConstructor(func() *bytes.Buffer {
+		return &bytes.Buffer{}
+	}, pool)
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	e1, err := injector.Get(&pooledEncoder{})
+	require.NoError(t, err)
+	e2, err := injector.Get(&pooledEncoder{})
+	require.NoError(t, err)
+	require.True(t, e1 != e2)
+	require.Equal(t, 2, created)
+
+	e1.(*pooledEncoder).buf.WriteString("data")
+	require.NoError(t, pool.Release(e1))
+	require.Equal(t, 0, e1.(*pooledEncoder).buf.Len())
+
+	// released twice
+	err = pool.Release(e1)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeNotPooled)
+	require.NoError(t, pool.Release(e2))
+
+	getEncoder := func() (interface{}, error) { return injector.Get(&pooledEncoder{}) }
+	requireReused(t, pool, getEncoder, getEncoder)
+
+	// bytes.Buffer is created by two bindings of the pool scope, each with
+	// its own pool
+	buf, err := injector.Get(&bytes.Buffer{})
+	require.NoError(t, err)
+	require.NoError(t, pool.Release(buf))
+	other, err := injector.GetTagged("other", &bytes.Buffer{})
+	require.NoError(t, err)
+	require.True(t, buf != other)
+	require.NoError(t, pool.Release(other))
+
+	// a child injector shares the pools of the bindings of its parent
+	child, err := injector.NewChildInjector(nil)
+	require.NoError(t, err)
+	requireReused(t, pool, getEncoder, func() (interface{}, error) { return child.Get(&pooledEncoder{}) })
+
+	err = pool.Release(&bytes.Buffer{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeNotPooled)
+}
+
+func TestPoolScopePerInjector(t *testing.T) {
+	pool := NewPoolScope()
+	module := NewModule()
+	module.BindConstructor(func() *pooledEncoder {
+		return &pooledEncoder{&bytes.Buffer{}}
+	}).In(pool)
+	injector1, err := NewInjector(module)
+	require.NoError(t, err)
+	injector2, err := NewInjector(module)
+	require.NoError(t, err)
+
+	e1, err := injector1.Get(&pooledEncoder{})
+	require.NoError(t, err)
+	require.NoError(t, pool.Release(e1))
+	e2, err := injector2.Get(&pooledEncoder{})
+	require.NoError(t, err)
+	require.True(t, e1 != e2)
+}
+
+func TestPoolScopeNotPoolable(t *testing.T) {
+	pool := NewPoolScope()
+	module := NewModule()
+	module.BindTagged("ids", []int{}).ToScopedConstructor(func() []int {
+		return []int{1}
+	}, pool)
+	module.BindTagged("buffer", bytes.Buffer{}).ToScopedConstructor(func() bytes.Buffer {
+		return bytes.Buffer{}
+	}, pool)
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	_, err = injector.GetTagged("ids", []int{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeNotPoolable)
+	// equal values could not be told apart
+	_, err = injector.GetTagged("buffer", bytes.Buffer{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeNotPoolable)
+	err = pool.Release([]int{1})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeNotPooled)
+}
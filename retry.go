@@ -0,0 +1,59 @@
+package inject
+
+import (
+	"context"
+	"time"
+)
+
+// RetryPolicy determines how often the constructor of a singleton is called
+// if it fails. See SingletonBuilder.WithRetry.
+//
+// The zero value calls the constructor exactly once and returns its error for
+// all subsequent requests of the singleton.
+type RetryPolicy struct {
+	// the maximum number of calls of the constructor per request
+	attempts int
+	// the delay before the second call, doubled for every further call
+	backoff time.Duration
+	// whether errors are cached
+	retryOnNextGet bool
+}
+
+// RetryOnNextGet returns a retry policy that does not cache errors: if the
+// constructor fails, it is called again the next time the singleton is
+// requested.
+func RetryOnNextGet() RetryPolicy {
+	return RetryPolicy{attempts: 1, retryOnNextGet: true}
+}
+
+// RetryWithBackoff returns a retry policy that calls the constructor up to the
+// given number of attempts, waiting for the given backoff before the second
+// attempt and doubling it for each further attempt. If all attempts fail, the
+// last error is returned for all subsequent requests of the singleton.
+func RetryWithBackoff(attempts int, backoff time.Duration) RetryPolicy {
+	return RetryPolicy{attempts: attempts, backoff: backoff}
+}
+
+// call calls f according to the retry policy and returns its result and
+// whether the result is final, i.e. needs to be cached. Waiting for the next
+// attempt is aborted if the given context is done.
+func (p RetryPolicy) call(c context.Context, f func() (interface{}, error)) (interface{}, bool, error) {
+	backoff := p.backoff
+	for attempt := 1; ; attempt++ {
+		value, err := f()
+		if err == nil {
+			return value, true, nil
+		}
+		if attempt >= p.attempts {
+			return nil, !p.retryOnNextGet, err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-c.Done():
+			timer.Stop()
+			return nil, false, errContextDone.withTag("err", c.Err()).withTag("lastErr", err.Error(), true)
+		}
+		backoff *= 2
+	}
+}
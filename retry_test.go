@@ -0,0 +1,96 @@
+package inject
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type retryDB struct {
+	attempt int32
+}
+
+// newFlakyDBConstructor returns a constructor that fails the given number of
+// times before it succeeds.
+func newFlakyDBConstructor(failures int32, calls *int32) func() (*retryDB, error) {
+	return func() (*retryDB, error) {
+		attempt := atomic.AddInt32(calls, 1)
+		if attempt <= failures {
+			return nil, errors.New("db not reachable")
+		}
+		return &retryDB{attempt}, nil
+	}
+}
+
+func TestSingletonErrorCachedByDefault(t *testing.T) {
+	var calls int32
+	module := NewModule()
+	module.BindSingletonConstructor(newFlakyDBConstructor(1, &calls))
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = injector.Get(&retryDB{})
+		require.Error(t, err)
+	}
+	require.Equal(t, int32(1), calls)
+}
+
+func TestSingletonRetryOnNextGet(t *testing.T) {
+	var calls int32
+	module := NewModule()
+	module.BindSingletonConstructor(newFlakyDBConstructor(1, &calls)).WithRetry(RetryOnNextGet())
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	_, err = injector.Get(&retryDB{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "db not reachable")
+
+	var wg sync.WaitGroup
+	dbs := make([]interface{}, 10)
+	for i := range dbs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dbs[i], _ = injector.Get(&retryDB{})
+		}(i)
+	}
+	wg.Wait()
+	for _, db := range dbs {
+		require.NotNil(t, db)
+		require.True(t, db == dbs[0])
+	}
+	require.Equal(t, int32(2), calls)
+}
+
+func TestSingletonRetryWithBackoff(t *testing.T) {
+	var calls int32
+	module := NewModule()
+	module.BindSingletonConstructor(newFlakyDBConstructor(2, &calls)).WithRetry(RetryWithBackoff(3, time.Millisecond)).Eagerly()
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	db, err := injector.Get(&retryDB{})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), db.(*retryDB).attempt)
+	require.Equal(t, int32(3), calls)
+}
+
+func TestSingletonRetryWithBackoffExhausted(t *testing.T) {
+	var calls int32
+	module := NewModule()
+	module.BindSingletonConstructor(newFlakyDBConstructor(5, &calls)).WithRetry(RetryWithBackoff(2, time.Millisecond))
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	_, err = injector.Get(&retryDB{})
+	require.Error(t, err)
+	_, err = injector.Get(&retryDB{})
+	require.Error(t, err)
+	require.Equal(t, int32(2), calls)
+}
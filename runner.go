@@ -0,0 +1,91 @@
+package inject
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// DefaultShutdownTimeout is the time a Runner grants its singletons for
+// stopping, unless configured otherwise.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// Starter is implemented by singletons that need to be started by Run, e.g.
+// servers that start listening or workers that start consuming a queue.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Runner runs an application built from a set of modules. See Run for
+// details.
+type Runner struct {
+	// Name is the name of the injector. Defaults to the caller's code location.
+	Name string
+	// ShutdownTimeout is the maximum time for stopping all singletons.
+	// Defaults to DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+	// Signals are the OS signals that trigger the shutdown of the application.
+	// Defaults to SIGINT and SIGTERM.
+	Signals []os.Signal
+}
+
+// Run runs an application with a Runner using the default configuration.
+func Run(ctx context.Context, modules ...Module) error {
+	r := &Runner{Name: callerName(3, "root")}
+	return r.Run(ctx, modules...)
+}
+
+// Run creates an injector for the given modules and starts all instantiated
+// singletons that implement Starter in dependency order. Usually these are
+// eager singletons (see SingletonBuilder.Eagerly) and their dependencies.
+//
+// Run then blocks until the given context is cancelled or one of the
+// configured OS signals is received, and finally closes the injector, which
+// stops all singletons in reverse order (see Injector.Close) within the
+// configured shutdown timeout.
+//
+// If a singleton fails to start, the injector is closed within the shutdown
+// timeout and the start error is returned. This stops the singletons that have
+// been started successfully, the singletons that do not implement Starter and
+// calls the cleanup functions, in reverse order. The singleton that failed to
+// start and the singletons that have not been started are not stopped.
+func (r *Runner) Run(ctx context.Context, modules ...Module) error {
+	name := r.Name
+	if name == "" {
+		name = callerName(3, "root")
+	}
+	inj, err := newInjector(ctx, name, modules...)
+	if err != nil {
+		return err
+	}
+
+	signals := r.Signals
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	runCtx, stop := signal.NotifyContext(ctx, signals...)
+	defer stop()
+
+	started, startErr := inj.lifecycle.start(runCtx)
+	if startErr == nil {
+		<-runCtx.Done()
+	}
+
+	timeout := r.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	closeCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if startErr != nil {
+		inj.lifecycle.forgetUnstarted(started)
+		if stopErr := inj.Close(closeCtx); stopErr != nil {
+			return unwrap(startErr).withTag("stop", stopErr.Error(), true)
+		}
+		return startErr
+	}
+	return inj.Close(closeCtx)
+}
@@ -0,0 +1,152 @@
+package inject
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type runLog struct {
+	mu      sync.Mutex
+	events  []string
+	started chan struct{}
+}
+
+func (l *runLog) add(event string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *runLog) get() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.events...)
+}
+
+type runComponent struct {
+	name     string
+	log      *runLog
+	startErr error
+}
+
+func (c *runComponent) Start(ctx context.Context) error {
+	c.log.add("start " + c.name)
+	return c.startErr
+}
+
+func (c *runComponent) Stop(ctx context.Context) error {
+	c.log.add("stop " + c.name)
+	return nil
+}
+
+type runStore struct{ *runComponent }
+
+type runServer struct{ *runComponent }
+
+type runCache struct{ *runComponent }
+
+// runConn is closed, but not started.
+type runConn struct{ log *runLog }
+
+func (c *runConn) Close() error {
+	c.log.add("close conn")
+	return nil
+}
+
+type runPool struct{}
+
+func newRunModule(log *runLog, serverErr error) Module {
+	module := NewModule()
+	module.BindSingleton(log)
+	module.BindSingletonConstructor(func(log *runLog) *runStore {
+		return &runStore{&runComponent{name: "store", log: log}}
+	})
+	module.BindSingletonConstructor(func(log *runLog, _ *runStore) *runServer {
+		return &runServer{&runComponent{name: "server", log: log, startErr: serverErr}}
+	}).Eagerly()
+	module.CallEagerly(func(log *runLog) {
+		close(log.started)
+	})
+	return module
+}
+
+func TestRun(t *testing.T) {
+	log := &runLog{started: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error)
+	go func() {
+		done <- Run(ctx, newRunModule(log, nil))
+	}()
+
+	<-log.started
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Run did not return after cancellation")
+	}
+	require.Equal(t, []string{"start store", "start server", "stop server", "stop store"}, log.get())
+}
+
+func TestRunStartFailure(t *testing.T) {
+	log := &runLog{started: make(chan struct{})}
+	runner := &Runner{ShutdownTimeout: time.Second}
+
+	err := runner.Run(context.Background(), newRunModule(log, errors.New("port in use")))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeStartFailed)
+	require.Contains(t, err.Error(), "port in use")
+	require.Equal(t, []string{"start store", "start server", "stop store"}, log.get())
+}
+
+func TestRunStartFailureStopsStartedInReverseOrder(t *testing.T) {
+	log := &runLog{started: make(chan struct{})}
+	module := NewModule()
+	module.BindSingleton(log)
+	module.BindSingletonConstructor(func(log *runLog) *runStore {
+		return &runStore{&runComponent{name: "store", log: log}}
+	})
+	module.BindSingletonConstructor(func(log *runLog, _ *runStore) *runCache {
+		return &runCache{&runComponent{name: "cache", log: log}}
+	})
+	module.BindSingletonConstructor(func(log *runLog, _ *runCache) *runServer {
+		return &runServer{&runComponent{name: "server", log: log, startErr: errors.New("port in use")}}
+	}).Eagerly()
+	runner := &Runner{ShutdownTimeout: time.Second}
+
+	err := runner.Run(context.Background(), module)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "port in use")
+	require.Equal(t, []string{"start store", "start cache", "start server", "stop cache", "stop store"}, log.get())
+}
+
+func TestRunStartFailureClosesNonStarters(t *testing.T) {
+	log := &runLog{started: make(chan struct{})}
+	module := NewModule()
+	module.BindSingleton(log)
+	module.BindSingletonConstructor(func(log *runLog) *runStore {
+		return &runStore{&runComponent{name: "store", log: log}}
+	})
+	module.BindSingletonConstructor(func(log *runLog, _ *runStore) *runConn {
+		return &runConn{log}
+	})
+	module.BindSingletonConstructor(func(log *runLog, _ *runConn) (*runPool, func()) {
+		return &runPool{}, func() { log.add("cleanup pool") }
+	})
+	module.BindSingletonConstructor(func(log *runLog, _ *runPool) *runServer {
+		return &runServer{&runComponent{name: "server", log: log, startErr: errors.New("port in use")}}
+	}).Eagerly()
+	runner := &Runner{ShutdownTimeout: time.Second}
+
+	err := runner.Run(context.Background(), module)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "port in use")
+	require.Equal(t, []string{"start store", "start server", "cleanup pool", "close conn", "stop store"}, log.get())
+}
@@ -0,0 +1,117 @@
+package inject
+
+import (
+	"context"
+	"fmt"
+)
+
+// Provider provides instances of a binding. The given context is passed to the
+// constructor of the binding and to the providers of its dependencies.
+type Provider func(ctx context.Context) (interface{}, error)
+
+// Scope determines the lifetime of the instances of scoped bindings, similar to
+// scopes in Guice. Bindings are transient (a new instance for every request)
+// or singletons (a single instance per injector) by default. A scope allows
+// other strategies, e.g. an instance per job, per batch or per tenant.
+//
+// See Builder.ToScopedConstructor and ConstructorBuilder.In.
+type Scope interface {
+	// String returns the name of the scope, which is shown in the dependency
+	// tree and in errors.
+	fmt.Stringer
+
+	// Scope returns the provider for the binding with the given key. It is
+	// called once per scoped binding when creating an injector. The unscoped
+	// provider creates a new instance by calling the constructor of the
+	// binding. Keys are comparable and may be used as map keys.
+	//
+	// The returned provider must be safe for concurrent use. Instances that
+	// need to be stopped are the responsibility of the scope, they are not
+	// stopped by Injector.Close.
+	Scope(key fmt.Stringer, unscoped Provider) Provider
+}
+
+// keyVerifier is implemented by scopes that only support the bindings of some
+// keys. The keys are verified when the bindings are installed in an injector.
+type keyVerifier interface {
+	verifyKey(key bindingKey) error
+}
+
+// lifecycleScope is implemented by scopes whose providers need to be stopped
+// when the injector is closed, e.g. to stop background work. Its scopeIn
+// method is called instead of Scope, with the lifecycle of the injector the
+// binding is installed in.
+type lifecycleScope interface {
+	scopeIn(key fmt.Stringer, unscoped Provider, lifecycle *lifecycle) Provider
+}
+
+// cleanupOwner is implemented by scopes that call the cleanup functions
+// returned by the constructors of their instances, see ContextScope.
+type cleanupOwner interface {
+	ownsCleanups()
+}
+
+// scopedBinding provides the instances of a resolved constructor binding
+// through a scope.
+type scopedBinding struct {
+	// the unscoped binding
+	resolvedBinding
+	scope    Scope
+	provider Provider
+}
+
+func newScopedBinding(injector *injector, key bindingKey, unscoped resolvedBinding, scope Scope) *scopedBinding {
+	s := &scopedBinding{resolvedBinding: unscoped, scope: scope}
+	if l, ok := scope.(lifecycleScope); ok {
+		s.provider = l.scopeIn(key, s.unscoped(injector, key), &injector.lifecycle)
+	} else {
+		s.provider = scope.Scope(key, s.unscoped(injector, key))
+	}
+	return s
+}
+
+func (s *scopedBinding) get(ctx ctx) (interface{}, error) {
+	r := &resolution{ctx: ctx}
+	defer r.end()
+	return s.provider(context.WithValue(ctx.context, resolutionKey{}, r))
+}
+
+// unscoped returns the provider that creates new instances with the unscoped
+// binding. The resolution continues on the path of the request that invoked
+// the scope's provider, if the provider calls it with the request's context
+// while handling the request. Otherwise a new resolution is started.
+func (s *scopedBinding) unscoped(injector *injector, key bindingKey) Provider {
+	return func(c context.Context) (interface{}, error) {
+		ctx, ok := resolutionFrom(c)
+		if !ok {
+			ctx = newRuntimeCtx(c, injector)
+			if err := ctx.push(key, s); err != nil {
+				return nil, err
+			}
+		}
+		ctx.context = c
+		if l, ok := c.Value(scopeLifecycleKey{}).(*scopeLifecycle); ok && l.key == key {
+			ctx.lifecycle = l.lifecycle
+		}
+		return s.resolvedBinding.get(ctx)
+	}
+}
+
+type scopeLifecycleKey struct{}
+
+// scopeLifecycle is the lifecycle that tracks the cleanup functions returned by
+// the constructor of the binding with the given key. It allows a built-in scope
+// to take over the cleanup of the instances it creates.
+type scopeLifecycle struct {
+	key       fmt.Stringer
+	lifecycle *lifecycle
+}
+
+type resolutionKey struct{}
+
+// resolutionFrom returns the resolution context of the request that invoked
+// the provider of a scope with the given context, if it is still running.
+func resolutionFrom(c context.Context) (ctx, bool) {
+	r, _ := c.Value(resolutionKey{}).(*resolution)
+	return r.active()
+}
@@ -0,0 +1,114 @@
+package inject
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type jobKey struct{}
+
+// jobScope is a scope with an instance per job, identified by a context value.
+type jobScope struct {
+	mu        sync.Mutex
+	instances map[interface{}]interface{}
+}
+
+func newJobScope() *jobScope {
+	return &jobScope{instances: make(map[interface{}]interface{})}
+}
+
+func (s *jobScope) String() string {
+	return "job"
+}
+
+func (s *jobScope) Scope(key fmt.Stringer, unscoped Provider) Provider {
+	return func(ctx context.Context) (interface{}, error) {
+		instanceKey := [2]interface{}{key, ctx.Value(jobKey{})}
+		s.mu.Lock()
+		instance, ok := s.instances[instanceKey]
+		s.mu.Unlock()
+		if ok {
+			return instance, nil
+		}
+		instance, err := unscoped(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if existing, ok := s.instances[instanceKey]; ok {
+			return existing, nil
+		}
+		s.instances[instanceKey] = instance
+		return instance, nil
+	}
+}
+
+type jobStats struct {
+	job string
+}
+
+type jobWorker struct {
+	stats *jobStats
+}
+
+func TestScopedConstructor(t *testing.T) {
+	scope := newJobScope()
+	module := NewModule()
+	module.Bind(&jobStats{}).ToScopedConstructor(func(ctx context.Context) *jobStats {
+		return &jobStats{ctx.Value(jobKey{}).(string)}
+	}, scope)
+	module.BindTagged("tagged", &jobStats{}).ToTaggedConstructor(func(s struct{ Stats *jobStats }) *jobStats {
+		return &jobStats{"tagged " + s.Stats.job}
+	}).In(scope)
+	module.BindConstructor(func(stats *jobStats) *jobWorker {
+		return &jobWorker{stats}
+	})
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	job1 := context.WithValue(context.Background(), jobKey{}, "1")
+	job2 := context.WithValue(context.Background(), jobKey{}, "2")
+
+	w1, err := injector.GetContext(job1, &jobWorker{})
+	require.NoError(t, err)
+	w2, err := injector.GetContext(job1, &jobWorker{})
+	require.NoError(t, err)
+	w3, err := injector.GetContext(job2, &jobWorker{})
+	require.NoError(t, err)
+	require.True(t, w1 != w2)
+	require.True(t, w1.(*jobWorker).stats == w2.(*jobWorker).stats)
+	require.Equal(t, "1", w1.(*jobWorker).stats.job)
+	require.Equal(t, "2", w3.(*jobWorker).stats.job)
+
+	tagged, err := injector.GetTaggedContext(job2, "tagged", &jobStats{})
+	require.NoError(t, err)
+	require.Equal(t, "tagged 2", tagged.(*jobStats).job)
+
+	tree, err := injector.DependencyTree()
+	require.NoError(t, err)
+	require.Contains(t, tree.String(), "{type:*inject.jobStats} : job <github.com/eluv-io/inject-go.TestScopedConstructor.func1(context.Context) *inject.jobStats>")
+	require.Contains(t, tree.String(), "{type:*inject.jobStats tag:tagged} : job <github.com/eluv-io/inject-go.TestScopedConstructor.func2(")
+}
+
+func TestScopedConstructorErrors(t *testing.T) {
+	module := NewModule()
+	module.Bind(&jobStats{}).ToScopedConstructor(func(w *jobWorker) *jobStats {
+		return &jobStats{}
+	}, newJobScope())
+	module.BindConstructor(func(inj Injector) (*jobWorker, error) {
+		_, err := inj.Get(&jobStats{})
+		return &jobWorker{}, err
+	})
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	_, err = injector.Get(&jobWorker{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeCircularDependency)
+	require.Contains(t, err.Error(), "{type:*inject.jobStats} : job <")
+}
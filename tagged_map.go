@@ -0,0 +1,118 @@
+package inject
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// taggedMapBinding provides the map of all tagged bindings of a type by tag,
+// including those of ancestor injectors. It is the implicit binding of
+// map[string]T for interface and pointer types T, unless that is bound
+// explicitly, e.g. as map binding. Maps of constants are never bound
+// implicitly, since their tags are unrelated configuration values.
+type taggedMapBinding struct {
+	injector    *injector
+	reflectType reflect.Type
+	keys        []taggedBindingKey
+}
+
+// newTaggedMapBinding returns the implicit binding of the given key of type
+// map[string]T in the given injector, or nil if the key is tagged, is not of
+// such a type, T is neither an interface nor a pointer type or there are no
+// tagged bindings of T.
+func newTaggedMapBinding(inj *injector, key bindingKey) *taggedMapBinding {
+	if _, tagged := key.(taggedBindingKey); tagged {
+		return nil
+	}
+	reflectType := key.reflectType()
+	if reflectType == nil || reflectType.Kind() != reflect.Map || reflectType.Key() != stringReflectType {
+		return nil
+	}
+	elemReflectType := reflectType.Elem()
+	switch elemReflectType.Kind() {
+	case reflect.Interface:
+		elemReflectType = reflect.PtrTo(elemReflectType)
+	case reflect.Ptr:
+	default:
+		return nil
+	}
+	keys := inj.taggedBindingKeys(elemReflectType)
+	if len(keys) == 0 {
+		return nil
+	}
+	return &taggedMapBinding{injector: inj, reflectType: reflectType, keys: keys}
+}
+
+func (t *taggedMapBinding) String() string {
+	tags := make([]string, len(t.keys))
+	for i, key := range t.keys {
+		tags[i] = key.tag
+	}
+	return fmt.Sprintf("tagged map [%s]", strings.Join(tags, ", "))
+}
+
+func (t *taggedMapBinding) validate(ctx ctx) error {
+	keys := make([]bindingKey, len(t.keys))
+	for i, key := range t.keys {
+		keys[i] = key
+	}
+	return t.injector.validateBindings(ctx, keys)
+}
+
+func (t *taggedMapBinding) get(ctx ctx) (interface{}, error) {
+	result := reflect.MakeMapWithSize(t.reflectType, len(t.keys))
+	for _, key := range t.keys {
+		value, err := t.injector.get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		reflectValue := reflect.Zero(t.reflectType.Elem())
+		if value != nil {
+			reflectValue = reflect.ValueOf(value)
+		}
+		result.SetMapIndex(reflect.ValueOf(key.tag), reflectValue)
+	}
+	return result.Interface(), nil
+}
+
+// taggedBindingKeys returns the keys of all tagged bindings of the given type
+// in this injector and its ancestors, sorted by tag.
+func (inj *injector) taggedBindingKeys(reflectType reflect.Type) []taggedBindingKey {
+	tags := make(map[string]bool)
+	var keys []taggedBindingKey
+	for i := inj; i != nil; i = i.parent {
+		for key := range i.bindings {
+			if tagged, ok := key.(taggedBindingKey); ok && tagged.reflectType() == reflectType && !tags[tagged.tag] {
+				tags[tagged.tag] = true
+				keys = append(keys, tagged)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].tag < keys[j].tag
+	})
+	return keys
+}
+
+func (inj *injector) GetAllTagged(from interface{}) (map[string]interface{}, error) {
+	return inj.GetAllTaggedContext(context.Background(), from)
+}
+
+func (inj *injector) GetAllTaggedContext(c context.Context, from interface{}) (map[string]interface{}, error) {
+	if err := inj.verifyNotClosed(); err != nil {
+		return nil, err
+	}
+	ctx := newRuntimeCtx(c, inj)
+	result := make(map[string]interface{})
+	for _, key := range inj.taggedBindingKeys(reflect.TypeOf(from)) {
+		value, err := inj.get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		result[key.tag] = value
+	}
+	return result, nil
+}
@@ -0,0 +1,115 @@
+package inject
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type router struct {
+	routes map[string]route
+}
+
+func TestTaggedMap(t *testing.T) {
+	m := NewModule()
+	m.BindSingleton(&routeConfig{"/api"})
+	m.BindTagged("users", (*route)(nil)).ToSingleton(staticRoute("/users"))
+	m.BindTagged("health", (*route)(nil)).ToConstructor(newHealthRoute)
+	m.BindSingletonConstructor(func(routes map[string]route) *router {
+		return &router{routes}
+	})
+	injector, err := NewInjector(m)
+	require.NoError(t, err)
+
+	expected := map[string]route{
+		"users":  staticRoute("/users"),
+		"health": staticRoute("/api/health"),
+	}
+	r, err := injector.Get(&router{})
+	require.NoError(t, err)
+	require.Equal(t, expected, r.(*router).routes)
+
+	all, err := injector.GetAllTagged((*route)(nil))
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"users":  staticRoute("/users"),
+		"health": staticRoute("/api/health"),
+	}, all)
+
+	tree, err := injector.DependencyTree()
+	require.NoError(t, err)
+	require.Contains(t, tree.String(), "{type:map[string]inject.route} : tagged map [health, users]")
+	require.Contains(t, tree.String(), "{type:*inject.route tag:health} : ")
+
+	t.Run("child injector", func(t *testing.T) {
+		cm := NewModule()
+		cm.BindTagged("admin", (*route)(nil)).ToSingleton(staticRoute("/admin"))
+		child, err := injector.NewChildInjector(nil, cm)
+		require.NoError(t, err)
+
+		routes, err := child.Get(map[string]route(nil))
+		require.NoError(t, err)
+		require.Len(t, routes, 3)
+		require.Equal(t, staticRoute("/admin"), routes.(map[string]route)["admin"])
+		all, err := child.GetAllTagged((*route)(nil))
+		require.NoError(t, err)
+		require.Len(t, all, 3)
+
+		// the parent does not see the tagged bindings of the child
+		routes, err = injector.Get(map[string]route(nil))
+		require.NoError(t, err)
+		require.Equal(t, expected, routes)
+	})
+}
+
+func TestTaggedMapExplicitBinding(t *testing.T) {
+	m := NewModule()
+	m.BindTagged("users", (*route)(nil)).ToSingleton(staticRoute("/users"))
+	m.BindMap(map[string]route(nil)).ToSingleton("health", staticRoute("/health"))
+	injector, err := NewInjector(m)
+	require.NoError(t, err)
+
+	routes, err := injector.Get(map[string]route(nil))
+	require.NoError(t, err)
+	require.Equal(t, map[string]route{"health": staticRoute("/health")}, routes)
+}
+
+func TestTaggedMapNoTaggedBindings(t *testing.T) {
+	m := NewModule()
+	m.Bind((*route)(nil)).ToSingleton(staticRoute("/users"))
+	injector, err := NewInjector(m)
+	require.NoError(t, err)
+
+	_, err = injector.Get(map[string]route(nil))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeNoBinding)
+
+	all, err := injector.GetAllTagged((*route)(nil))
+	require.NoError(t, err)
+	require.Empty(t, all)
+}
+
+func TestTaggedMapNotForConstants(t *testing.T) {
+	m := NewModule()
+	m.BindTaggedString("db_password").ToSingleton("secret")
+	m.BindTaggedString("db_user").ToSingleton("admin")
+	m.BindSingletonConstructor(func(config map[string]string) *routeConfig {
+		return &routeConfig{config["prefix"]}
+	})
+	_, err := NewInjector(m)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeNoBinding)
+	require.Contains(t, err.Error(), "bindingKey:{type:map[string]string}")
+}
+
+func TestTaggedMapMissingDependency(t *testing.T) {
+	m := NewModule()
+	m.BindTagged("health", (*route)(nil)).ToConstructor(newHealthRoute)
+	m.BindSingletonConstructor(func(routes map[string]route) *router {
+		return &router{routes}
+	})
+	_, err := NewInjector(m)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeNoBinding)
+	require.Contains(t, err.Error(), "bindingKey:{type:*inject.routeConfig}")
+}
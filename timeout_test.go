@@ -0,0 +1,162 @@
+package inject
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type timeoutClient struct{}
+
+type timeoutService struct {
+	client *timeoutClient
+}
+
+func newHangingClient(ctx context.Context) (*timeoutClient, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func newTimeoutService(client *timeoutClient) *timeoutService {
+	return &timeoutService{client}
+}
+
+func TestConstructorTimeout(t *testing.T) {
+	module := NewModule()
+	module.BindConstructor(newHangingClient).WithTimeout(10 * time.Millisecond)
+	module.BindSingletonConstructor(newTimeoutService)
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	_, err = injector.Get(&timeoutService{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeTimeout)
+	require.Contains(t, err.Error(), "timeout:10ms")
+	require.Contains(t, err.Error(), "* {type:*inject.timeoutService}")
+	require.Contains(t, err.Error(), "* {type:*inject.timeoutClient}")
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestEagerSingletonTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	module := NewModule()
+	module.BindSingletonConstructor(func() *timeoutClient {
+		<-release // ignores the context
+		return &timeoutClient{}
+	}).WithTimeout(10 * time.Millisecond).Eagerly()
+
+	done := make(chan error)
+	go func() {
+		_, err := NewInjector(module)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		require.Error(t, err)
+		require.Contains(t, err.Error(), injectErrorTypeTimeout)
+		require.Contains(t, err.Error(), "eager:{type:*inject.timeoutClient}")
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "NewInjector did not time out")
+	}
+}
+
+func TestConstructorWithinTimeout(t *testing.T) {
+	module := NewModule()
+	module.BindSingletonConstructor(func() *timeoutClient {
+		return &timeoutClient{}
+	}).WithTimeout(time.Second)
+	module.BindSingletonConstructor(newTimeoutService)
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	service, err := injector.Get(&timeoutService{})
+	require.NoError(t, err)
+	require.NotNil(t, service.(*timeoutService).client)
+}
+
+type timeoutResource struct {
+	closed int32
+}
+
+func (r *timeoutResource) Close() error {
+	atomic.AddInt32(&r.closed, 1)
+	return nil
+}
+
+func TestTimeoutLateSingletonAdopted(t *testing.T) {
+	release := make(chan struct{})
+	var calls int32
+	module := NewModule()
+	module.BindSingletonConstructor(func() *timeoutResource {
+		atomic.AddInt32(&calls, 1)
+		<-release // ignores the context
+		return &timeoutResource{}
+	}).WithTimeout(10 * time.Millisecond).WithRetry(RetryOnNextGet())
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	_, err = injector.Get(&timeoutResource{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeTimeout)
+
+	// the retry waits for the abandoned call instead of calling the
+	// constructor again
+	_, err = injector.Get(&timeoutResource{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), injectErrorTypeTimeout)
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	close(release)
+	var first interface{}
+	waitFor(t, func() bool {
+		first, err = injector.Get(&timeoutResource{})
+		return err == nil
+	})
+	second, err := injector.Get(&timeoutResource{})
+	require.NoError(t, err)
+	require.True(t, first == second)
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// the adopted singleton is closed with the injector
+	require.NoError(t, injector.Close(context.Background()))
+	require.Equal(t, int32(1), atomic.LoadInt32(&first.(*timeoutResource).closed))
+}
+
+func TestTimeoutLateResultDiscarded(t *testing.T) {
+	for name, bind := range map[string]func(module Module, constructor func() *timeoutResource){
+		"singleton": func(module Module, constructor func() *timeoutResource) {
+			module.BindSingletonConstructor(constructor).WithTimeout(10 * time.Millisecond)
+		},
+		"transient": func(module Module, constructor func() *timeoutResource) {
+			module.BindConstructor(constructor).WithTimeout(10 * time.Millisecond)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			release := make(chan struct{})
+			created := make(chan *timeoutResource, 1)
+			module := NewModule()
+			bind(module, func() *timeoutResource {
+				<-release // ignores the context
+				r := &timeoutResource{}
+				created <- r
+				return r
+			})
+			injector, err := NewInjector(module)
+			require.NoError(t, err)
+
+			_, err = injector.Get(&timeoutResource{})
+			require.Error(t, err)
+			require.Contains(t, err.Error(), injectErrorTypeTimeout)
+
+			close(release)
+			r := <-created
+			waitFor(t, func() bool { return atomic.LoadInt32(&r.closed) == 1 })
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package inject
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Clock provides the current time. It allows testing time-dependent scopes
+// deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// TTLScope is a scope for instances that must be rebuilt periodically, e.g.
+// credentials or remote configuration. An instance is cached for the TTL and
+// recreated with the constructor of the binding on the first request after it
+// has expired:
+//
+//	module.BindConstructor(newCredentials).In(&inject.TTLScope{
+//		TTL:          time.Hour,
+//		RefreshAhead: 5 * time.Minute,
+//	})
+//
+// With RefreshAhead, the first request within that duration before the
+// expiration triggers a refresh in the background, while the current instance
+// is still returned. If the refresh fails, the last good instance keeps being
+// served until it expires, and the next request triggers a new refresh.
+// Instances refreshed in the background receive a context that is cancelled
+// when the injector is closed, instead of the context of the request. Closing
+// the injector stops the background refreshes and waits for running ones.
+//
+// Instances of all bindings in the scope have the same TTL. Expired instances
+// are not stopped.
+type TTLScope struct {
+	// TTL is the time an instance is cached.
+	TTL time.Duration
+	// RefreshAhead is the time before the expiration of an instance in which
+	// it is refreshed in the background. Zero disables background refreshes.
+	RefreshAhead time.Duration
+	// Clock provides the current time. Defaults to the system clock.
+	Clock Clock
+}
+
+func (s *TTLScope) String() string {
+	return fmt.Sprintf("ttl %s", s.TTL)
+}
+
+func (s *TTLScope) Scope(key fmt.Stringer, unscoped Provider) Provider {
+	return s.scopeIn(key, unscoped, nil)
+}
+
+// scopeIn returns the provider for the binding with the given key, whose
+// background refreshes are stopped by the given lifecycle, if any.
+func (s *TTLScope) scopeIn(key fmt.Stringer, unscoped Provider, lifecycle *lifecycle) Provider {
+	refreshCtx, cancel := context.WithCancel(context.Background())
+	e := &ttlEntry{
+		scope:      s,
+		key:        key,
+		unscoped:   unscoped,
+		lifecycle:  lifecycle,
+		refreshCtx: refreshCtx,
+		cancel:     cancel,
+	}
+	return e.get
+}
+
+func (s *TTLScope) now() time.Time {
+	if s.Clock == nil {
+		return systemClock{}.Now()
+	}
+	return s.Clock.Now()
+}
+
+// ttlEntry holds the instance of a single binding in a TTLScope.
+type ttlEntry struct {
+	scope    *TTLScope
+	key      fmt.Stringer
+	unscoped Provider
+	// the lifecycle that stops the background refreshes, if any
+	lifecycle *lifecycle
+	// the context of background refreshes, cancelled by Stop
+	refreshCtx context.Context
+	cancel     context.CancelFunc
+	// the running background refreshes
+	refreshes sync.WaitGroup
+
+	// mu serializes the creation of instances and protects the fields below
+	mu         sync.Mutex
+	value      interface{}
+	expires    time.Time
+	valid      bool
+	refreshing bool
+	tracked    bool
+	stopped    bool
+}
+
+func (e *ttlEntry) get(ctx context.Context) (interface{}, error) {
+	value, track, err := e.load(ctx)
+	if track {
+		// tracked after the dependencies of the first instance have been
+		// created, so that the refreshes are stopped before them
+		e.lifecycle.track(e.key.String(), e)
+	}
+	return value, err
+}
+
+// load returns the cached instance or creates a new one, and whether the entry
+// needs to be tracked by its lifecycle.
+func (e *ttlEntry) load(ctx context.Context) (interface{}, bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.scope.now()
+	if e.valid && now.Before(e.expires) {
+		if e.scope.RefreshAhead > 0 && !e.refreshing && !e.stopped && !now.Before(e.expires.Add(-e.scope.RefreshAhead)) {
+			e.refreshing = true
+			e.refreshes.Add(1)
+			go e.refresh()
+		}
+		return e.value, false, nil
+	}
+
+	value, err := e.unscoped(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	e.set(value)
+	track := !e.tracked && e.lifecycle != nil && e.scope.RefreshAhead > 0
+	e.tracked = true
+	return value, track, nil
+}
+
+// refresh recreates the instance in the background. The current instance is
+// kept if that fails or if the refreshes have been stopped in the meantime.
+func (e *ttlEntry) refresh() {
+	defer e.refreshes.Done()
+	value, err := e.unscoped(e.refreshCtx)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.refreshing = false
+	if err == nil && !e.stopped {
+		e.set(value)
+	}
+}
+
+// Stop stops the background refreshes, cancelling the context of a running
+// refresh and waiting for it to return.
+func (e *ttlEntry) Stop(ctx context.Context) error {
+	e.mu.Lock()
+	e.stopped = true
+	e.mu.Unlock()
+	e.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		e.refreshes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// set caches the given instance. Must be called with the lock held.
+func (e *ttlEntry) set(value interface{}) {
+	e.value = value
+	e.expires = e.scope.now().Add(e.scope.TTL)
+	e.valid = true
+}
@@ -0,0 +1,178 @@
+package inject
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type ttlCredentials struct {
+	version int
+}
+
+// ttlSource creates credentials with increasing versions, failing if told so.
+type ttlSource struct {
+	mu       sync.Mutex
+	version  int
+	fail     bool
+	attempts int
+}
+
+func (s *ttlSource) newCredentials() (*ttlCredentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts++
+	if s.fail {
+		return nil, errors.New("credentials service unavailable")
+	}
+	s.version++
+	return &ttlCredentials{s.version}, nil
+}
+
+func (s *ttlSource) getAttempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts
+}
+
+func (s *ttlSource) setFail(fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail = fail
+}
+
+// waitFor waits until the given condition is true.
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			require.Fail(t, "condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func getCredentialsVersion(t *testing.T, injector Injector) int {
+	value, err := injector.Get(&ttlCredentials{})
+	require.NoError(t, err)
+	return value.(*ttlCredentials).version
+}
+
+func TestTTLScope(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	source := &ttlSource{}
+	module := NewModule()
+	module.BindConstructor(source.newCredentials).In(&TTLScope{TTL: time.Minute, Clock: clock})
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, getCredentialsVersion(t, injector))
+	clock.advance(59 * time.Second)
+	require.Equal(t, 1, getCredentialsVersion(t, injector))
+	clock.advance(time.Second)
+	require.Equal(t, 2, getCredentialsVersion(t, injector))
+
+	// errors are not cached
+	clock.advance(time.Minute)
+	source.setFail(true)
+	_, err = injector.Get(&ttlCredentials{})
+	require.Error(t, err)
+	source.setFail(false)
+	require.Equal(t, 3, getCredentialsVersion(t, injector))
+
+	tree, err := injector.DependencyTree()
+	require.NoError(t, err)
+	require.Contains(t, tree.String(), "{type:*inject.ttlCredentials} : ttl 1m0s <")
+}
+
+func TestTTLScopeRefreshAhead(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	source := &ttlSource{}
+	module := NewModule()
+	module.BindConstructor(source.newCredentials).In(&TTLScope{TTL: time.Minute, RefreshAhead: 10 * time.Second, Clock: clock})
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, getCredentialsVersion(t, injector))
+
+	// a failed refresh keeps the last good value
+	clock.advance(50 * time.Second)
+	source.setFail(true)
+	require.Equal(t, 1, getCredentialsVersion(t, injector))
+	waitFor(t, func() bool {
+		return source.getAttempts() == 2
+	})
+	require.Equal(t, 1, getCredentialsVersion(t, injector))
+
+	// subsequent requests trigger another refresh, which succeeds
+	source.setFail(false)
+	waitFor(t, func() bool {
+		return getCredentialsVersion(t, injector) == 2
+	})
+}
+
+func TestTTLScopeRefreshStoppedOnClose(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	var mu sync.Mutex
+	calls := 0
+	refreshing := make(chan struct{})
+	refreshed := make(chan struct{})
+	module := NewModule()
+	module.BindConstructor(func(ctx context.Context) (*ttlCredentials, error) {
+		mu.Lock()
+		calls++
+		call := calls
+		mu.Unlock()
+		if call == 1 {
+			return &ttlCredentials{1}, nil
+		}
+		// the refresh blocks until the injector is closed
+		defer close(refreshed)
+		close(refreshing)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}).In(&TTLScope{TTL: time.Minute, RefreshAhead: 10 * time.Second, Clock: clock})
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, getCredentialsVersion(t, injector))
+	clock.advance(50 * time.Second)
+	require.Equal(t, 1, getCredentialsVersion(t, injector))
+	<-refreshing
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, injector.Close(ctx))
+	select {
+	case <-refreshed:
+	default:
+		require.Fail(t, "refresh still running after Close")
+	}
+
+	// no refresh is started after Close
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, 2, calls)
+}
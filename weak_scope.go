@@ -0,0 +1,77 @@
+//go:build go1.24
+
+package inject
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"weak"
+)
+
+// WeakScope returns a scope for large instances of type *T, e.g. caches, that
+// should be shared while they are in use, but not be kept alive by the
+// injector. The scope holds its instances through weak pointers: an instance
+// is reused as long as something else references it, and recreated with the
+// constructor of the binding once it has been garbage collected.
+//
+//	module.BindConstructor(newCache).In(inject.WeakScope[Cache]())
+//
+// This fills the gap between transient bindings, which create a new instance
+// for every request, and singletons, which are kept alive by the injector.
+// Only bindings of type *T can be in the scope, where T is not an interface.
+// The WeakScope requires Go 1.24 or later.
+func WeakScope[T any]() Scope {
+	return weakScope[T]{}
+}
+
+type weakScope[T any] struct{}
+
+func (w weakScope[T]) String() string {
+	return "weak"
+}
+
+func (w weakScope[T]) Scope(key fmt.Stringer, unscoped Provider) Provider {
+	e := &weakEntry[T]{key: key, unscoped: unscoped}
+	return e.get
+}
+
+func (w weakScope[T]) verifyKey(key bindingKey) error {
+	t := reflect.TypeOf((*T)(nil))
+	if key.reflectType() != t || isInterface(t.Elem()) {
+		return errWeakNotPointer.withTag("bindingKey", key).withTag("type", t)
+	}
+	return nil
+}
+
+// weakEntry holds the instance of a single binding in the WeakScope.
+type weakEntry[T any] struct {
+	key      fmt.Stringer
+	unscoped Provider
+
+	// mu serializes the creation of instances and protects the pointer
+	mu sync.Mutex
+	// the weak pointer to the instance
+	ptr weak.Pointer[T]
+}
+
+func (e *weakEntry[T]) get(ctx context.Context) (interface{}, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if p := e.ptr.Value(); p != nil {
+		return p, nil
+	}
+
+	value, err := e.unscoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p, _ := value.(*T)
+	if p == nil {
+		return nil, errWeakNotPointer.withTag("bindingKey", e.key).withTag("type", reflect.TypeOf(value))
+	}
+	e.ptr = weak.Make(p)
+	return p, nil
+}
@@ -0,0 +1,90 @@
+//go:build go1.24
+
+package inject
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type weakCache struct {
+	id   int
+	data [1 << 16]byte
+}
+
+type weakStruct struct{}
+
+func TestWeakScope(t *testing.T) {
+	created := 0
+	module := NewModule()
+	module.BindConstructor(func() *weakCache {
+		created++
+		return &weakCache{id: created}
+	}).In(WeakScope[weakCache]())
+	injector, err := NewInjector(module)
+	require.NoError(t, err)
+
+	first, err := injector.Get(&weakCache{})
+	require.NoError(t, err)
+	runtime.GC()
+	second, err := injector.Get(&weakCache{})
+	require.NoError(t, err)
+	require.True(t, first == second)
+	require.Equal(t, 1, created)
+	runtime.KeepAlive(first)
+
+	first, second = nil, nil
+	runtime.GC()
+	third, err := injector.Get(&weakCache{})
+	require.NoError(t, err)
+	require.Equal(t, 2, third.(*weakCache).id)
+	require.Equal(t, 2, created)
+
+	tree, err := injector.DependencyTree()
+	require.NoError(t, err)
+	require.Contains(t, tree.String(), "{type:*inject.weakCache} : weak <")
+}
+
+func TestWeakScopeRequiresPointers(t *testing.T) {
+	for name, bind := range map[string]func(module Module){
+		"struct": func(module Module) {
+			module.BindConstructor(func() weakStruct {
+				return weakStruct{}
+			}).In(WeakScope[weakStruct]())
+		},
+		"other type": func(module Module) {
+			module.BindConstructor(func() *weakStruct {
+				return &weakStruct{}
+			}).In(WeakScope[weakCache]())
+		},
+		"interface": func(module Module) {
+			module.Bind((*tracer)(nil)).ToConstructor(func() tracer {
+				return prefixTracer("")
+			}).In(WeakScope[tracer]())
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			module := NewModule()
+			bind(module)
+			_, err := NewInjector(module)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), injectErrorTypeWeakNotPointer)
+		})
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		module := NewModule()
+		module.BindConstructor(func() *weakCache {
+			return nil
+		}).In(WeakScope[weakCache]())
+		injector, err := NewInjector(module)
+		require.NoError(t, err)
+
+		_, err = injector.Get(&weakCache{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), injectErrorTypeWeakNotPointer)
+		require.Contains(t, err.Error(), "bindingKey:{type:*inject.weakCache}")
+	})
+}